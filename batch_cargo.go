@@ -0,0 +1,259 @@
+package zczy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotBatchCargo 摘单通知不属于批量货（YardID为空）
+var ErrNotBatchCargo = errors.New("不是批量货子单")
+
+// CreateBatchOrderRequest 创建批量货订单请求
+// 订单信息、货物和收发货信息与普通货相同，母单总吨位为货物重量之和，由多辆车分别摘单形成子单
+type CreateBatchOrderRequest struct {
+	CreateOrderRequest
+	VehicleWeight Quantity `json:"vehicleWeight"` // 单车最大装货吨位
+}
+
+// CreateBatchOrderResponse 创建批量货订单响应
+type CreateBatchOrderResponse struct {
+	YardID string `json:"yardId"` // 母单号，与摘单通知中的 YardID 对应
+}
+
+// TotalWeight 返回母单总吨位（货物重量之和）
+func (r *CreateBatchOrderRequest) TotalWeight() (Quantity, error) {
+	var total Decimal
+	for i, cargo := range r.CargoList {
+		w, err := cargo.Weight.Decimal()
+		if err != nil {
+			return "", fmt.Errorf("cargoList[%d].weight: %w", i, err)
+		}
+		total = total.Add(w)
+	}
+	return NewQuantity(total, RoundHalfUp), nil
+}
+
+// Validate 校验批量货订单请求，在普通货规则的基础上要求单车吨位大于0且不超过母单总吨位
+func (r *CreateBatchOrderRequest) Validate() error {
+	v := &validator{}
+	r.CreateOrderRequest.validate(v)
+	if v.required("vehicleWeight", string(r.VehicleWeight)) {
+		before := len(v.errs)
+		v.quantity("vehicleWeight", r.VehicleWeight)
+		if len(v.errs) == before {
+			weight, _ := r.VehicleWeight.Decimal()
+			total, err := r.TotalWeight()
+			totalValue, _ := total.Decimal()
+			switch {
+			case weight.Sign() <= 0:
+				v.addf("vehicleWeight", "必须大于0，实际为%q", r.VehicleWeight)
+			case err == nil && weight.Cmp(totalValue) > 0:
+				v.addf("vehicleWeight", "单车吨位%s超过母单总吨位%s", r.VehicleWeight, total)
+			}
+		}
+	}
+	return v.err()
+}
+
+// CreateBatchOrder 创建批量货订单，返回母单号
+func (c *Client) CreateBatchOrder(req *CreateBatchOrderRequest) (*CreateBatchOrderResponse, error) {
+	resp, err := c.Execute(MethodOrderCreateBatch, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result CreateBatchOrderResponse
+	if err := resp.GetData(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// BatchSubOrder 批量货子单
+type BatchSubOrder struct {
+	OrderID        string         // 子单号
+	SelfComment    string         // 自定义单号
+	Weight         Quantity       // 摘单吨位
+	ConsignorState ConsignorState // 承运状态
+	PlateNumber    string         // 车牌号
+	DriverUserName string         // 司机姓名
+	DelistTime     PlatformTime   // 摘牌时间
+}
+
+// BatchOrder 批量货母单及其子单
+type BatchOrder struct {
+	YardID      string          // 母单号
+	TotalWeight Quantity        // 母单总吨位，未登记时为空
+	SubOrders   []BatchSubOrder // 子单，按首次收到摘单通知的顺序
+	UpdatedAt   time.Time       // 最后更新时间
+}
+
+// DelistedWeight 返回未终止子单的摘单吨位之和，已终止子单的吨位退回母单
+func (b *BatchOrder) DelistedWeight() Decimal {
+	var total Decimal
+	for _, sub := range b.SubOrders {
+		if sub.ConsignorState == ConsignorStateTerminated {
+			continue
+		}
+		w, _ := sub.Weight.Decimal()
+		total = total.Add(w)
+	}
+	return total
+}
+
+// RemainingWeight 返回母单剩余可摘吨位，未登记总吨位时返回false
+func (b *BatchOrder) RemainingWeight() (Decimal, bool) {
+	if b.TotalWeight == "" {
+		return Decimal{}, false
+	}
+	total, _ := b.TotalWeight.Decimal()
+	return total.Sub(b.DelistedWeight()), true
+}
+
+// Exceeded 判断子单摘单吨位之和是否超过母单总吨位
+func (b *BatchOrder) Exceeded() bool {
+	remaining, ok := b.RemainingWeight()
+	return ok && remaining.Sign() < 0
+}
+
+// SubOrder 按子单号查询
+func (b *BatchOrder) SubOrder(orderID string) (*BatchSubOrder, bool) {
+	for i := range b.SubOrders {
+		if b.SubOrders[i].OrderID == orderID {
+			return &b.SubOrders[i], true
+		}
+	}
+	return nil, false
+}
+
+// clone 返回深拷贝
+func (b *BatchOrder) clone() *BatchOrder {
+	c := *b
+	c.SubOrders = append([]BatchSubOrder(nil), b.SubOrders...)
+	return &c
+}
+
+// apply 合并子单的摘单通知，重复或乱序到达的状态不会回退子单状态
+func (b *BatchOrder) apply(n *DelistNotification, now time.Time) {
+	sub, ok := b.SubOrder(n.OrderID)
+	if !ok {
+		b.SubOrders = append(b.SubOrders, BatchSubOrder{OrderID: n.OrderID})
+		sub = &b.SubOrders[len(b.SubOrders)-1]
+	}
+
+	for dst, src := range map[*string]string{
+		&sub.SelfComment:    n.SelfComment,
+		&sub.PlateNumber:    n.PlateNumber,
+		&sub.DriverUserName: n.DriverUserName,
+	} {
+		if src != "" {
+			*dst = src
+		}
+	}
+	if n.Weight != "" {
+		sub.Weight = n.Weight
+	}
+	if n.DelistTime != "" {
+		sub.DelistTime = n.DelistTime
+	}
+	if _, err := checkTransition(sub.ConsignorState, n.ConsignorState); err == nil {
+		sub.ConsignorState = n.ConsignorState
+	}
+	b.UpdatedAt = now
+}
+
+// BatchOrderBook 按母单号汇总批量货子单的摘单通知，可并发使用
+type BatchOrderBook struct {
+	client *Client
+	mu     sync.Mutex
+	orders map[string]*BatchOrder
+}
+
+// NewBatchOrderBook 创建批量货汇总，client 仅用于 HandleCallback 验签，只调用 Apply 时可以为nil
+func NewBatchOrderBook(client *Client) *BatchOrderBook {
+	return &BatchOrderBook{client: client, orders: make(map[string]*BatchOrder)}
+}
+
+// Register 登记母单总吨位，通常在 CreateBatchOrder 成功后调用；子单通知可以早于登记到达
+func (b *BatchOrderBook) Register(yardID string, totalWeight Quantity) error {
+	if yardID == "" {
+		return errors.New("yardId is required")
+	}
+	if _, err := totalWeight.Decimal(); err != nil {
+		return fmt.Errorf("母单总吨位格式错误: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.order(yardID).TotalWeight = totalWeight
+	return nil
+}
+
+// Apply 将子单的摘单通知归入母单，返回更新后的母单副本；普通货通知返回 ErrNotBatchCargo
+func (b *BatchOrderBook) Apply(n *DelistNotification) (*BatchOrder, error) {
+	if n.YardID == "" {
+		return nil, fmt.Errorf("%w: orderId=%s", ErrNotBatchCargo, n.OrderID)
+	}
+	if n.OrderID == "" {
+		return nil, errors.New("orderId is required")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	order := b.order(n.YardID)
+	order.apply(n, time.Now())
+	return order.clone(), nil
+}
+
+// HandleCallback 验签并处理摘单通知，普通货及其他类型的通知被忽略，可作为CallbackHandler使用
+// 创建时未传入 client 则无法验签，返回错误
+func (b *BatchOrderBook) HandleCallback(req *CallbackRequest) error {
+	if b.client == nil {
+		return errors.New("client is required to verify callbacks")
+	}
+	event, err := b.client.DecodeCallback(req)
+	if err != nil {
+		return err
+	}
+	if n, ok := event.(*DelistNotification); ok && n.YardID != "" {
+		_, err = b.Apply(n)
+	}
+	return err
+}
+
+// Get 按母单号查询，返回副本
+func (b *BatchOrderBook) Get(yardID string) (*BatchOrder, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	order, ok := b.orders[yardID]
+	if !ok {
+		return nil, false
+	}
+	return order.clone(), true
+}
+
+// List 返回全部母单副本，按母单号排序
+func (b *BatchOrderBook) List() []*BatchOrder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	orders := make([]*BatchOrder, 0, len(b.orders))
+	for _, order := range b.orders {
+		orders = append(orders, order.clone())
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].YardID < orders[j].YardID })
+	return orders
+}
+
+// order 返回母单，不存在时新建，调用方需持有锁
+func (b *BatchOrderBook) order(yardID string) *BatchOrder {
+	order, ok := b.orders[yardID]
+	if !ok {
+		order = &BatchOrder{YardID: yardID}
+		b.orders[yardID] = order
+	}
+	return order
+}
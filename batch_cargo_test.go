@@ -0,0 +1,157 @@
+package zczy
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func newValidCreateBatchOrderRequest() *CreateBatchOrderRequest {
+	return &CreateBatchOrderRequest{
+		CreateOrderRequest: *newValidCreateOrderRequest(),
+		VehicleWeight:      "32",
+	}
+}
+
+func TestCreateBatchOrderRequestValidate(t *testing.T) {
+	req := newValidCreateBatchOrderRequest()
+	req.CargoList = append(req.CargoList, req.CargoList[0])
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if total, _ := req.TotalWeight(); total != "60.0000" {
+		t.Errorf("TotalWeight() = %s, want 60.0000", total)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *CreateBatchOrderRequest)
+		path   string
+	}{
+		{"缺少单车吨位", func(r *CreateBatchOrderRequest) { r.VehicleWeight = "" }, "vehicleWeight"},
+		{"单车吨位为0", func(r *CreateBatchOrderRequest) { r.VehicleWeight = "0" }, "vehicleWeight"},
+		{"单车吨位超过总吨位", func(r *CreateBatchOrderRequest) { r.VehicleWeight = "60.5" }, "vehicleWeight"},
+		{"普通货规则", func(r *CreateBatchOrderRequest) { r.OrderInfo.VehicleType = "" }, "orderInfo.vehicleType"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newValidCreateBatchOrderRequest()
+			req.CargoList = append(req.CargoList, req.CargoList[0])
+			tt.modify(req)
+			var errs ValidationErrors
+			if !errors.As(req.Validate(), &errs) || errs.Field(tt.path) == nil {
+				t.Errorf("Validate() error = %v, want %s", errs, tt.path)
+			}
+		})
+	}
+}
+
+func TestClientCreateBatchOrder(t *testing.T) {
+	var gotMethod string
+	var gotParams map[string]json.RawMessage
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		gotMethod = method
+		json.Unmarshal([]byte(params), &gotParams)
+		return &Response{Code: "0000", Result: map[string]string{"yardId": "YARD001"}}
+	})
+
+	resp, err := client.CreateBatchOrder(newValidCreateBatchOrderRequest())
+	if err != nil {
+		t.Fatalf("CreateBatchOrder() error = %v", err)
+	}
+	if resp.YardID != "YARD001" {
+		t.Errorf("YardID = %s, want YARD001", resp.YardID)
+	}
+	if gotMethod != MethodOrderCreateBatch {
+		t.Errorf("method = %s, want %s", gotMethod, MethodOrderCreateBatch)
+	}
+	for _, key := range []string{"orderInfo", "cargoList", "orderAddressInfo", "vehicleWeight"} {
+		if _, ok := gotParams[key]; !ok {
+			t.Errorf("请求参数缺少 %s", key)
+		}
+	}
+}
+
+func TestBatchOrderBook(t *testing.T) {
+	book := NewBatchOrderBook(nil)
+
+	// 子单通知早于登记到达
+	if _, err := book.Apply(&DelistNotification{YardID: "YARD001", OrderID: "SUB1", Weight: "20", ConsignorState: ConsignorStateDelisted}); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.Register("YARD001", "60"); err != nil {
+		t.Fatal(err)
+	}
+	book.Apply(&DelistNotification{YardID: "YARD001", OrderID: "SUB2", Weight: "25", ConsignorState: ConsignorStateDelisted})
+	book.Apply(&DelistNotification{YardID: "YARD001", OrderID: "SUB1", ConsignorState: ConsignorStateShipped})
+	// 乱序到达的摘单通知不回退状态
+	order, err := book.Apply(&DelistNotification{YardID: "YARD001", OrderID: "SUB1", Weight: "20", ConsignorState: ConsignorStateDelisted})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(order.SubOrders) != 2 {
+		t.Fatalf("子单数量 = %d, want 2", len(order.SubOrders))
+	}
+	if sub, _ := order.SubOrder("SUB1"); sub.ConsignorState != ConsignorStateShipped || sub.Weight != "20" {
+		t.Errorf("SUB1 = %+v", sub)
+	}
+	if got := order.DelistedWeight().String(); got != "45" {
+		t.Errorf("DelistedWeight() = %s, want 45", got)
+	}
+	if remaining, ok := order.RemainingWeight(); !ok || remaining.String() != "15" {
+		t.Errorf("RemainingWeight() = %s, %v, want 15", remaining, ok)
+	}
+
+	// 终止的子单吨位退回母单
+	book.Apply(&DelistNotification{YardID: "YARD001", OrderID: "SUB2", ConsignorState: ConsignorStateTerminated})
+	book.Apply(&DelistNotification{YardID: "YARD001", OrderID: "SUB3", Weight: "45", ConsignorState: ConsignorStateDelisted})
+	order, _ = book.Get("YARD001")
+	if got := order.DelistedWeight().String(); got != "65" || !order.Exceeded() {
+		t.Errorf("DelistedWeight() = %s, Exceeded() = %v, want 65, true", got, order.Exceeded())
+	}
+
+	if _, err := book.Apply(&DelistNotification{OrderID: "ZC001"}); !errors.Is(err, ErrNotBatchCargo) {
+		t.Errorf("Apply(普通货) error = %v, want ErrNotBatchCargo", err)
+	}
+	if list := book.List(); len(list) != 1 || list[0].YardID != "YARD001" {
+		t.Errorf("List() = %v", list)
+	}
+}
+
+func TestBatchOrderBookHandleCallback(t *testing.T) {
+	client := newTestCallbackClient()
+	book := NewBatchOrderBook(client)
+
+	req := newSignedCallbackRequest(t, client, map[string]any{
+		"orderModel":     "0",
+		"orderId":        "SUB1",
+		"yardId":         "YARD001",
+		"consignorState": "5",
+		"weight":         "30",
+		"plateNumber":    "苏A12345",
+	})
+	if err := book.HandleCallback(req); err != nil {
+		t.Fatalf("HandleCallback() error = %v", err)
+	}
+	order, ok := book.Get("YARD001")
+	if !ok || len(order.SubOrders) != 1 || order.SubOrders[0].Weight != "30" {
+		t.Errorf("Get() = %+v, %v", order, ok)
+	}
+
+	// 普通货通知被忽略
+	req = newSignedCallbackRequest(t, client, map[string]any{
+		"orderModel":     "0",
+		"orderId":        "ZC001",
+		"consignorState": "5",
+		"plateNumber":    "苏A12345",
+	})
+	if err := book.HandleCallback(req); err != nil {
+		t.Errorf("HandleCallback(普通货) error = %v", err)
+	}
+
+	// 未传入 client 时无法验签
+	if err := NewBatchOrderBook(nil).HandleCallback(req); err == nil {
+		t.Error("HandleCallback() error = nil, want error")
+	}
+}
@@ -13,32 +13,32 @@ import (
 
 // DelistNotification 摘单通知回调数据
 type DelistNotification struct {
-	OrderModel        string `json:"orderModel"`        // 0-抢单，1-竞价
-	OrderID           string `json:"orderId"`           // 订单号，如果是批量货，就是子单号
-	YardID            string `json:"yardId"`            // 如果为批量货，就为母单号，普通货为空
-	SelfComment       string `json:"selfComment"`       // 自定义单号
-	ConsignorUserName string `json:"consignorUserName"` // 货主名称
-	ConsignorMobile   string `json:"consignorMobile"`   // 货主名称手机号
-	ConsignorState    string `json:"consignorState"`    // 承运状态：5-摘单，6-确认发货，7-确认收货，8-已终止
-	CargoName         string `json:"cargoName"`         // 货物名称
-	CarrierName       string `json:"carrierName"`       // 承运方姓名
-	CarrierMobile     string `json:"carrierMobile"`     // 承运方手机号
-	DelistTime        string `json:"delistTime"`        // 摘牌时间，格式：yyyy-mm-dd hh:mm:ss
-	Weight            string `json:"weight"`            // 摘单吨位
-	PlateNumber       string `json:"plateNumber"`       // 车牌号
-	DriverUserName    string `json:"driverUserName"`    // 司机姓名
-	DriverMobile      string `json:"driverMobile"`      // 司机手机号
-	SafeguardCost     string `json:"safeguardCost"`     // 保险费
+	OrderModel        OrderModel     `json:"orderModel"`        // 订单类型：0-抢单，1-竞价（解析后为抢单/竞价）
+	OrderID           string         `json:"orderId"`           // 订单号，如果是批量货，就是子单号
+	YardID            string         `json:"yardId"`            // 如果为批量货，就为母单号，普通货为空
+	SelfComment       string         `json:"selfComment"`       // 自定义单号
+	ConsignorUserName string         `json:"consignorUserName"` // 货主名称
+	ConsignorMobile   string         `json:"consignorMobile"`   // 货主名称手机号
+	ConsignorState    ConsignorState `json:"consignorState"`    // 承运状态：5-摘单，6-确认发货，7-确认收货，8-已终止
+	CargoName         string         `json:"cargoName"`         // 货物名称
+	CarrierName       string         `json:"carrierName"`       // 承运方姓名
+	CarrierMobile     string         `json:"carrierMobile"`     // 承运方手机号
+	DelistTime        PlatformTime   `json:"delistTime"`        // 摘牌时间，格式：yyyy-mm-dd hh:mm:ss
+	Weight            Quantity       `json:"weight"`            // 摘单吨位
+	PlateNumber       string         `json:"plateNumber"`       // 车牌号
+	DriverUserName    string         `json:"driverUserName"`    // 司机姓名
+	DriverMobile      string         `json:"driverMobile"`      // 司机手机号
+	SafeguardCost     Money          `json:"safeguardCost"`     // 保险费
 }
 
 // BreachResultNotification 违约结果通知回调数据
 type BreachResultNotification struct {
-	OrderID         string `json:"orderId"`         // 订单号
-	ConsignorState  string `json:"consignorState"`  // 运单状态
-	Operation       string `json:"operation"`       // 操作：1-同意，2-驳回（拒绝）
-	ConsignorAmount string `json:"consignorAmount"` // 违约金额
-	IsStop          string `json:"isStop"`          // 运单是否终止：1-是，0-否
-	PlatformResults string `json:"platformResults"` // 是否最终处理结果（固定值1）
+	OrderID         string         `json:"orderId"`         // 订单号
+	ConsignorState  ConsignorState `json:"consignorState"`  // 运单状态
+	Operation       string         `json:"operation"`       // 操作：1-同意，2-驳回（拒绝）
+	ConsignorAmount Money          `json:"consignorAmount"` // 违约金额
+	IsStop          string         `json:"isStop"`          // 运单是否终止：1-是，0-否
+	PlatformResults string         `json:"platformResults"` // 是否最终处理结果（固定值1）
 }
 
 // CallbackRequest 回调请求（包含验签参数）
@@ -0,0 +1,153 @@
+package zczy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownEventType 无法识别的回调通知类型
+var ErrUnknownEventType = errors.New("无法识别的回调通知类型")
+
+// EventType 回调通知类型
+type EventType string
+
+const (
+	// EventTypeDelist 摘单通知
+	EventTypeDelist EventType = "delist"
+	// EventTypeBreachResult 违约结果通知
+	EventTypeBreachResult EventType = "breach_result"
+)
+
+// Event 回调事件，DecodeCallback按通知类型返回对应的结构体指针
+// 示例：
+//
+//	switch e := event.(type) {
+//	case *zczy.DelistNotification:
+//		// 摘单通知
+//	case *zczy.BreachResultNotification:
+//		// 违约结果通知
+//	}
+type Event interface {
+	EventType() EventType
+}
+
+// EventDetector 根据回调业务数据的字段判断是否为某类通知
+type EventDetector func(fields map[string]json.RawMessage) bool
+
+// eventRegistration 回调通知类型注册信息
+type eventRegistration struct {
+	eventType EventType
+	detect    EventDetector
+	newEvent  func() Event
+}
+
+var (
+	eventRegistryMu sync.RWMutex
+	eventRegistry   []eventRegistration
+)
+
+func init() {
+	// 违约结果通知特有 operation/platformResults 字段
+	mustRegisterEventType(EventTypeBreachResult, func(fields map[string]json.RawMessage) bool {
+		return hasAnyField(fields, "operation", "platformResults", "isStop")
+	}, func() Event { return &BreachResultNotification{} })
+
+	// 摘单通知特有 delistTime/plateNumber 等字段
+	mustRegisterEventType(EventTypeDelist, func(fields map[string]json.RawMessage) bool {
+		return hasAnyField(fields, "delistTime", "plateNumber", "carrierName", "driverUserName")
+	}, func() Event { return &DelistNotification{} })
+}
+
+// EventType 返回回调通知类型
+func (n *DelistNotification) EventType() EventType {
+	return EventTypeDelist
+}
+
+// EventType 返回回调通知类型
+func (n *BreachResultNotification) EventType() EventType {
+	return EventTypeBreachResult
+}
+
+// RegisterEventType 注册新的回调通知类型，供DecodeCallback自动识别
+// 按注册顺序依次检测，内置的违约结果通知、摘单通知最先注册
+func RegisterEventType(eventType EventType, detect EventDetector, newEvent func() Event) error {
+	if eventType == "" {
+		return errors.New("eventType is required")
+	}
+	if detect == nil {
+		return errors.New("detect is required")
+	}
+	if newEvent == nil {
+		return errors.New("newEvent is required")
+	}
+
+	eventRegistryMu.Lock()
+	defer eventRegistryMu.Unlock()
+
+	for _, reg := range eventRegistry {
+		if reg.eventType == eventType {
+			return fmt.Errorf("回调通知类型已注册: %s", eventType)
+		}
+	}
+	eventRegistry = append(eventRegistry, eventRegistration{
+		eventType: eventType,
+		detect:    detect,
+		newEvent:  newEvent,
+	})
+	return nil
+}
+
+// mustRegisterEventType 注册内置回调通知类型
+func mustRegisterEventType(eventType EventType, detect EventDetector, newEvent func() Event) {
+	if err := RegisterEventType(eventType, detect, newEvent); err != nil {
+		panic(err)
+	}
+}
+
+// DecodeCallback 验证签名并自动识别回调通知类型
+// 适用于平台只配置了一个回调地址的场景
+func (c *Client) DecodeCallback(req *CallbackRequest) (Event, error) {
+	if err := c.VerifyCallbackSign(req); err != nil {
+		return nil, fmt.Errorf("签名验证失败: %v", err)
+	}
+
+	return decodeCallbackData(req.Data)
+}
+
+// decodeCallbackData 根据业务数据字段识别通知类型并解析
+func decodeCallbackData(data string) (Event, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &fields); err != nil {
+		return nil, fmt.Errorf("解析业务数据失败: %v", err)
+	}
+
+	eventRegistryMu.RLock()
+	registry := make([]eventRegistration, len(eventRegistry))
+	copy(registry, eventRegistry)
+	eventRegistryMu.RUnlock()
+
+	for _, reg := range registry {
+		if !reg.detect(fields) {
+			continue
+		}
+		event := reg.newEvent()
+		if err := json.Unmarshal([]byte(data), event); err != nil {
+			return nil, fmt.Errorf("解析业务数据失败: %v", err)
+		}
+		return event, nil
+	}
+
+	return nil, ErrUnknownEventType
+}
+
+// hasAnyField 判断业务数据中是否包含任一字段
+func hasAnyField(fields map[string]json.RawMessage, names ...string) bool {
+	for _, name := range names {
+		if _, ok := fields[name]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package zczy
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestDecodeCallback(t *testing.T) {
+	client := newTestCallbackClient()
+
+	t.Run("摘单通知", func(t *testing.T) {
+		req := newSignedCallbackRequest(t, client, DelistNotification{
+			OrderID:     "102019010101018811",
+			PlateNumber: "苏A12345",
+			DelistTime:  "2025-01-18 10:00:00",
+		})
+
+		event, err := client.DecodeCallback(req)
+		if err != nil {
+			t.Fatalf("DecodeCallback() error = %v", err)
+		}
+		delist, ok := event.(*DelistNotification)
+		if !ok {
+			t.Fatalf("事件类型 = %T, want *DelistNotification", event)
+		}
+		if delist.PlateNumber != "苏A12345" {
+			t.Errorf("PlateNumber = %s", delist.PlateNumber)
+		}
+		if event.EventType() != EventTypeDelist {
+			t.Errorf("EventType() = %s", event.EventType())
+		}
+	})
+
+	t.Run("违约结果通知", func(t *testing.T) {
+		req := newSignedCallbackRequest(t, client, BreachResultNotification{
+			OrderID:         "102019010101018811",
+			Operation:       "1",
+			PlatformResults: "1",
+		})
+
+		event, err := client.DecodeCallback(req)
+		if err != nil {
+			t.Fatalf("DecodeCallback() error = %v", err)
+		}
+		breach, ok := event.(*BreachResultNotification)
+		if !ok {
+			t.Fatalf("事件类型 = %T, want *BreachResultNotification", event)
+		}
+		if breach.Operation != "1" {
+			t.Errorf("Operation = %s", breach.Operation)
+		}
+	})
+
+	t.Run("无法识别的通知", func(t *testing.T) {
+		req := newSignedCallbackRequest(t, client, map[string]string{"foo": "bar"})
+
+		_, err := client.DecodeCallback(req)
+		if !errors.Is(err, ErrUnknownEventType) {
+			t.Errorf("DecodeCallback() error = %v, want ErrUnknownEventType", err)
+		}
+	})
+
+	t.Run("签名错误", func(t *testing.T) {
+		req := newSignedCallbackRequest(t, client, DelistNotification{OrderID: "102019010101018811"})
+		req.Sign = "INVALID_SIGN"
+
+		if _, err := client.DecodeCallback(req); err == nil {
+			t.Error("签名错误时应返回错误")
+		}
+	})
+}
+
+// testSettleNotification 测试用的自定义通知类型
+type testSettleNotification struct {
+	OrderID     string `json:"orderId"`
+	SettleMoney string `json:"settleMoney"`
+}
+
+func (n *testSettleNotification) EventType() EventType {
+	return "test_settle"
+}
+
+func TestRegisterEventType(t *testing.T) {
+	err := RegisterEventType("test_settle", func(fields map[string]json.RawMessage) bool {
+		return hasAnyField(fields, "settleMoney")
+	}, func() Event { return &testSettleNotification{} })
+	if err != nil {
+		t.Fatalf("RegisterEventType() error = %v", err)
+	}
+
+	client := newTestCallbackClient()
+	req := newSignedCallbackRequest(t, client, testSettleNotification{
+		OrderID:     "102019010101018811",
+		SettleMoney: "580.00",
+	})
+
+	event, err := client.DecodeCallback(req)
+	if err != nil {
+		t.Fatalf("DecodeCallback() error = %v", err)
+	}
+	settle, ok := event.(*testSettleNotification)
+	if !ok {
+		t.Fatalf("事件类型 = %T, want *testSettleNotification", event)
+	}
+	if settle.SettleMoney != "580.00" {
+		t.Errorf("SettleMoney = %s", settle.SettleMoney)
+	}
+
+	// 重复注册应返回错误
+	err = RegisterEventType("test_settle", func(map[string]json.RawMessage) bool { return false }, func() Event { return &testSettleNotification{} })
+	if err == nil {
+		t.Error("重复注册应返回错误")
+	}
+}
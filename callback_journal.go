@@ -0,0 +1,399 @@
+package zczy
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// CallbackJournalConfig 回调日志配置
+type CallbackJournalConfig struct {
+	Dir         string // 日志目录（必填）
+	FilePrefix  string // 文件名前缀，默认callbacks
+	MaxFileSize int64  // 单个文件最大字节数，超过后滚动到新文件，默认100MB
+}
+
+// JournalEntry 回调日志记录
+type JournalEntry struct {
+	ReceivedAt  time.Time `json:"receivedAt"`            // 接收时间
+	Verified    bool      `json:"verified"`              // 是否验签通过
+	VerifyError string    `json:"verifyError,omitempty"` // 验签失败原因
+	OrderID     string    `json:"orderId,omitempty"`     // 业务数据中的订单号
+	Body        string    `json:"body"`                  // 原始请求体（合法UTF-8时）
+	BodyBase64  string    `json:"bodyBase64,omitempty"`  // 原始请求体的base64编码（不是合法UTF-8时，Body为空）
+}
+
+// RawBody 返回与接收时逐字节一致的原始请求体
+func (e *JournalEntry) RawBody() ([]byte, error) {
+	if e.BodyBase64 != "" {
+		return base64.StdEncoding.DecodeString(e.BodyBase64)
+	}
+	return []byte(e.Body), nil
+}
+
+// Request 将原始请求体解析为回调请求
+func (e *JournalEntry) Request() (*CallbackRequest, error) {
+	body, err := e.RawBody()
+	if err != nil {
+		return nil, fmt.Errorf("解码原始请求体失败: %v", err)
+	}
+	var req CallbackRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("解析回调请求失败: %v", err)
+	}
+	return &req, nil
+}
+
+// JournalFilter 回调日志筛选条件，零值字段表示不限制
+type JournalFilter struct {
+	From              time.Time // 接收时间下限（含）
+	To                time.Time // 接收时间上限（不含）
+	OrderID           string    // 订单号
+	IncludeUnverified bool      // 是否包含验签失败的记录
+}
+
+// match 判断记录是否符合筛选条件
+func (f *JournalFilter) match(e *JournalEntry) bool {
+	if !f.IncludeUnverified && !e.Verified {
+		return false
+	}
+	if !f.From.IsZero() && e.ReceivedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !e.ReceivedAt.Before(f.To) {
+		return false
+	}
+	if f.OrderID != "" && e.OrderID != f.OrderID {
+		return false
+	}
+	return true
+}
+
+// ReplayResult 回放结果
+type ReplayResult struct {
+	Total     int           // 符合条件的记录数
+	Succeeded int           // 处理成功数
+	Failed    int           // 处理失败数
+	Errors    []ReplayError // 失败明细
+}
+
+// ReplayError 回放失败明细
+type ReplayError struct {
+	Entry *JournalEntry
+	Err   error
+}
+
+// CallbackJournal 追加写入的回调日志
+// 以JSON Lines格式原样记录每一个回调请求，按天和文件大小滚动，支持按条件回放
+type CallbackJournal struct {
+	config CallbackJournalConfig
+
+	mu      sync.Mutex
+	file    *os.File
+	day     string
+	index   int
+	size    int64
+	nowFunc func() time.Time
+}
+
+// NewCallbackJournal 创建回调日志
+func NewCallbackJournal(config *CallbackJournalConfig) (*CallbackJournal, error) {
+	if config == nil || config.Dir == "" {
+		return nil, errors.New("dir is required")
+	}
+
+	cfg := *config
+	if cfg.FilePrefix == "" {
+		cfg.FilePrefix = "callbacks"
+	}
+	if cfg.MaxFileSize <= 0 {
+		cfg.MaxFileSize = 100 * 1024 * 1024
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create journal dir error: %w", err)
+	}
+
+	return &CallbackJournal{config: cfg, nowFunc: time.Now}, nil
+}
+
+// Record 记录一个回调请求体及其验签结果
+func (j *CallbackJournal) Record(body []byte, verifyErr error) (*JournalEntry, error) {
+	entry := &JournalEntry{
+		ReceivedAt: j.nowFunc(),
+		Verified:   verifyErr == nil,
+	}
+	// JSON字符串会把非法UTF-8替换为U+FFFD，此时改用base64保证回放时逐字节一致
+	if utf8.Valid(body) {
+		entry.Body = string(body)
+	} else {
+		entry.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+	if verifyErr != nil {
+		entry.VerifyError = verifyErr.Error()
+	}
+
+	var req CallbackRequest
+	if err := json.Unmarshal(body, &req); err == nil {
+		entry.OrderID = callbackOrderID(req.Data)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("marshal journal entry error: %w", err)
+	}
+	data = append(data, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.rotate(entry.ReceivedAt, int64(len(data))); err != nil {
+		return nil, err
+	}
+	n, err := j.file.Write(data)
+	j.size += int64(n)
+	if err != nil {
+		return nil, fmt.Errorf("write journal error: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Receive 解析并验签回调请求体，无论验签是否通过都写入日志
+func (j *CallbackJournal) Receive(client *Client, body []byte) (*CallbackRequest, error) {
+	var req CallbackRequest
+	verifyErr := json.Unmarshal(body, &req)
+	if verifyErr != nil {
+		verifyErr = fmt.Errorf("解析回调请求失败: %v", verifyErr)
+	} else {
+		verifyErr = client.VerifyCallbackSign(&req)
+	}
+
+	if _, err := j.Record(body, verifyErr); err != nil {
+		return nil, err
+	}
+	if verifyErr != nil {
+		return nil, verifyErr
+	}
+	return &req, nil
+}
+
+// Handler 返回记录日志后调用业务处理函数的http.Handler
+// 回放时使用同一个handler，保证线上处理与回放逻辑一致
+func (j *CallbackJournal) Handler(client *Client, handler CallbackHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeCallbackResponse(w, http.StatusBadRequest, "9999", "读取请求失败")
+			return
+		}
+
+		req, err := j.Receive(client, body)
+		if err != nil {
+			writeCallbackResponse(w, http.StatusUnauthorized, "9999", err.Error())
+			return
+		}
+
+		if err := handler(req); err != nil {
+			writeCallbackResponse(w, http.StatusInternalServerError, "9999", err.Error())
+			return
+		}
+		writeCallbackResponse(w, http.StatusOK, "0000", "success")
+	})
+}
+
+// Entries 按筛选条件读取日志记录，按文件顺序返回
+func (j *CallbackJournal) Entries(filter JournalFilter) ([]*JournalEntry, error) {
+	var entries []*JournalEntry
+	err := j.scan(func(e *JournalEntry) error {
+		if filter.match(e) {
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// Replay 将符合条件的日志记录依次交给handler重新处理
+// 单条记录处理失败不会中断回放，失败明细记录在返回结果中
+func (j *CallbackJournal) Replay(filter JournalFilter, handler CallbackHandler) (*ReplayResult, error) {
+	result := &ReplayResult{}
+	err := j.scan(func(e *JournalEntry) error {
+		if !filter.match(e) {
+			return nil
+		}
+		result.Total++
+
+		req, err := e.Request()
+		if err == nil {
+			err = handler(req)
+		}
+		if err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, ReplayError{Entry: e, Err: err})
+			return nil
+		}
+		result.Succeeded++
+		return nil
+	})
+	return result, err
+}
+
+// Close 关闭当前日志文件
+func (j *CallbackJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// rotate 按日期和文件大小切换日志文件，调用方需持有mu
+func (j *CallbackJournal) rotate(now time.Time, size int64) error {
+	day := now.Format("20060102")
+	if j.file != nil && day == j.day && j.size+size <= j.config.MaxFileSize {
+		return nil
+	}
+
+	if j.file == nil || day != j.day {
+		// 新的一天或首次写入时，续写当天最后一个文件
+		j.day = day
+		j.index = j.lastIndex(day)
+	} else {
+		j.index++
+	}
+
+	for {
+		if j.file != nil {
+			j.file.Close()
+			j.file = nil
+		}
+
+		f, err := os.OpenFile(j.filePath(j.day, j.index), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("open journal file error: %w", err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("stat journal file error: %w", err)
+		}
+
+		j.file = f
+		j.size = info.Size()
+		// 空文件总是可以写入，避免单条记录超过MaxFileSize时无限滚动
+		if j.size == 0 || j.size+size <= j.config.MaxFileSize {
+			return nil
+		}
+		j.index++
+	}
+}
+
+// filePath 返回日志文件路径，序号补零便于查看；超过999个文件时由 scan 按数值排序
+func (j *CallbackJournal) filePath(day string, index int) string {
+	return filepath.Join(j.config.Dir, fmt.Sprintf("%s-%s-%03d.jsonl", j.config.FilePrefix, day, index))
+}
+
+// lastIndex 返回某天已存在的最大文件序号
+func (j *CallbackJournal) lastIndex(day string) int {
+	files, _ := filepath.Glob(filepath.Join(j.config.Dir, j.config.FilePrefix+"-"+day+"-*.jsonl"))
+	last := 0
+	for _, f := range files {
+		if _, index, ok := journalFileKey(f); ok && index > last {
+			last = index
+		}
+	}
+	return last
+}
+
+// journalFileKey 从日志文件名中解析日期和序号
+func journalFileKey(path string) (day string, index int, ok bool) {
+	name := strings.TrimSuffix(filepath.Base(path), ".jsonl")
+	i := strings.LastIndex(name, "-")
+	if i < 0 {
+		return "", 0, false
+	}
+	if _, err := fmt.Sscanf(name[i+1:], "%d", &index); err != nil {
+		return "", 0, false
+	}
+	name = name[:i]
+	return name[strings.LastIndex(name, "-")+1:], index, true
+}
+
+// scan 按时间顺序遍历所有日志记录
+func (j *CallbackJournal) scan(fn func(e *JournalEntry) error) error {
+	files, err := filepath.Glob(filepath.Join(j.config.Dir, j.config.FilePrefix+"-*.jsonl"))
+	if err != nil {
+		return fmt.Errorf("list journal files error: %w", err)
+	}
+	// 按日期、序号数值排序，序号超过三位时字典序不再等于时间顺序
+	sort.SliceStable(files, func(a, b int) bool {
+		dayA, indexA, okA := journalFileKey(files[a])
+		dayB, indexB, okB := journalFileKey(files[b])
+		if !okA || !okB || dayA != dayB {
+			return files[a] < files[b]
+		}
+		return indexA < indexB
+	})
+
+	for _, path := range files {
+		if err := scanJournalFile(path, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanJournalFile 遍历单个日志文件
+func scanJournalFile(path string, fn func(e *JournalEntry) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open journal file error: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("unmarshal journal entry %s error: %w", filepath.Base(path), err)
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read journal file error: %w", err)
+	}
+	return nil
+}
+
+// callbackOrderID 从回调业务数据中提取订单号
+func callbackOrderID(data string) string {
+	var fields struct {
+		OrderID string `json:"orderId"`
+	}
+	if err := json.Unmarshal([]byte(data), &fields); err != nil {
+		return ""
+	}
+	return fields.OrderID
+}
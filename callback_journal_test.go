@@ -0,0 +1,197 @@
+package zczy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCallbackJournalReceiveAndReplay(t *testing.T) {
+	client := newTestCallbackClient()
+	journal, err := NewCallbackJournal(&CallbackJournalConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewCallbackJournal() error = %v", err)
+	}
+	defer journal.Close()
+
+	for _, orderID := range []string{"A001", "A002", "A001"} {
+		req := newSignedCallbackRequest(t, client, DelistNotification{OrderID: orderID})
+		body, _ := json.Marshal(req)
+		if _, err := journal.Receive(client, body); err != nil {
+			t.Fatalf("Receive() error = %v", err)
+		}
+	}
+
+	// 验签失败的请求同样要记录
+	bad := newSignedCallbackRequest(t, client, DelistNotification{OrderID: "A003"})
+	bad.Sign = "INVALID_SIGN"
+	badBody, _ := json.Marshal(bad)
+	if _, err := journal.Receive(client, badBody); err == nil {
+		t.Fatal("签名错误时Receive()应返回错误")
+	}
+
+	all, err := journal.Entries(JournalFilter{IncludeUnverified: true})
+	if err != nil {
+		t.Fatalf("Entries() error = %v", err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("记录数 = %d, want 4", len(all))
+	}
+	if all[3].Verified || all[3].VerifyError == "" {
+		t.Errorf("验签失败的记录应标记为未通过")
+	}
+	if all[3].Body != string(badBody) {
+		t.Errorf("应原样记录请求体")
+	}
+
+	var replayed []string
+	result, err := journal.Replay(JournalFilter{OrderID: "A001"}, func(req *CallbackRequest) error {
+		replayed = append(replayed, callbackOrderID(req.Data))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if result.Total != 2 || result.Succeeded != 2 || len(replayed) != 2 {
+		t.Errorf("Replay() = %+v, replayed = %v", result, replayed)
+	}
+}
+
+func TestCallbackJournalTimeFilter(t *testing.T) {
+	journal, err := NewCallbackJournal(&CallbackJournalConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewCallbackJournal() error = %v", err)
+	}
+	defer journal.Close()
+
+	base := time.Date(2025, 1, 18, 23, 0, 0, 0, time.Local)
+	for i := 0; i < 4; i++ {
+		now := base.Add(time.Duration(i) * time.Hour)
+		journal.nowFunc = func() time.Time { return now }
+		if _, err := journal.Record([]byte(`{"data":"{\"orderId\":\"A001\"}"}`), nil); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	// 跨天后应切换到新文件
+	files, _ := filepath.Glob(filepath.Join(journal.config.Dir, "*.jsonl"))
+	if len(files) != 2 {
+		t.Errorf("文件数 = %d, want 2", len(files))
+	}
+
+	entries, err := journal.Entries(JournalFilter{From: base.Add(time.Hour), To: base.Add(3 * time.Hour)})
+	if err != nil {
+		t.Fatalf("Entries() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("时间范围内的记录数 = %d, want 2", len(entries))
+	}
+	if entries[0].OrderID != "A001" {
+		t.Errorf("OrderID = %s, want A001", entries[0].OrderID)
+	}
+}
+
+func TestCallbackJournalRotateBySize(t *testing.T) {
+	journal, err := NewCallbackJournal(&CallbackJournalConfig{Dir: t.TempDir(), MaxFileSize: 200})
+	if err != nil {
+		t.Fatalf("NewCallbackJournal() error = %v", err)
+	}
+
+	body := bytes.Repeat([]byte("x"), 100)
+	for i := 0; i < 3; i++ {
+		if _, err := journal.Record(body, nil); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	journal.Close()
+
+	files, _ := filepath.Glob(filepath.Join(journal.config.Dir, "*.jsonl"))
+	if len(files) != 3 {
+		t.Errorf("文件数 = %d, want 3", len(files))
+	}
+
+	entries, err := journal.Entries(JournalFilter{})
+	if err != nil {
+		t.Fatalf("Entries() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("记录数 = %d, want 3", len(entries))
+	}
+}
+
+func TestCallbackJournalHandler(t *testing.T) {
+	client := newTestCallbackClient()
+	journal, err := NewCallbackJournal(&CallbackJournalConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewCallbackJournal() error = %v", err)
+	}
+	defer journal.Close()
+
+	handled := 0
+	handler := journal.Handler(client, func(req *CallbackRequest) error {
+		handled++
+		return nil
+	})
+
+	req := newSignedCallbackRequest(t, client, DelistNotification{OrderID: "A001"})
+	body, _ := json.Marshal(req)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader(body)))
+
+	if rec.Code != http.StatusOK || handled != 1 {
+		t.Errorf("状态码 = %d, 处理次数 = %d", rec.Code, handled)
+	}
+}
+
+func TestCallbackJournalOrderBeyond999Files(t *testing.T) {
+	journal, err := NewCallbackJournal(&CallbackJournalConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewCallbackJournal() error = %v", err)
+	}
+	// 序号1000的文件名在字典序上排在999之前
+	for _, index := range []int{999, 1000} {
+		f, _ := os.Create(journal.filePath("20250120", index))
+		line, _ := json.Marshal(JournalEntry{OrderID: fmt.Sprint(index), Verified: true})
+		f.Write(append(line, '\n'))
+		f.Close()
+	}
+	if got := journal.lastIndex("20250120"); got != 1000 {
+		t.Errorf("lastIndex() = %d, want 1000", got)
+	}
+
+	entries, err := journal.Entries(JournalFilter{})
+	if err != nil {
+		t.Fatalf("Entries() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].OrderID != "999" || entries[1].OrderID != "1000" {
+		t.Errorf("Entries() 顺序 = %v, %v", entries[0].OrderID, entries[1].OrderID)
+	}
+}
+
+func TestCallbackJournalNonUTF8Body(t *testing.T) {
+	journal, err := NewCallbackJournal(&CallbackJournalConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewCallbackJournal() error = %v", err)
+	}
+	defer journal.Close()
+
+	body := []byte("{\"data\":\"\xb6\xa9\xb5\xa5\"}") // GBK编码的"订单"
+	if _, err := journal.Record(body, errors.New("签名错误")); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	entries, _ := journal.Entries(JournalFilter{IncludeUnverified: true})
+	if len(entries) != 1 {
+		t.Fatalf("记录数 = %d, want 1", len(entries))
+	}
+	raw, err := entries[0].RawBody()
+	if err != nil || !bytes.Equal(raw, body) {
+		t.Errorf("RawBody() = %q, %v, want %q", raw, err, body)
+	}
+}
@@ -0,0 +1,534 @@
+package zczy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCallbackQueueFull 回调队列已满，平台会在稍后重新推送
+var ErrCallbackQueueFull = errors.New("回调队列已满")
+
+// ErrCallbackQueueClosed 回调队列已关闭
+var ErrCallbackQueueClosed = errors.New("回调队列已关闭")
+
+// CallbackHandler 回调业务处理函数
+// 返回错误时，回调队列会按配置进行重试
+type CallbackHandler func(req *CallbackRequest) error
+
+// CallbackQueueConfig 回调异步处理队列配置
+type CallbackQueueConfig struct {
+	PendingDir     string        // 待处理回调的持久化目录（必填）
+	DeadLetterPath string        // 死信文件路径（JSON Lines格式），默认为PendingDir下的dead_letter.jsonl
+	Workers        int           // 并发处理的worker数量，默认4
+	QueueSize      int           // 内存队列容量，默认100
+	MaxRetries     int           // 最大重试次数（不含首次处理），默认3，设为负数表示不重试
+	RetryBackoff   time.Duration // 首次重试等待时间，之后按指数递增，默认1秒
+	MaxBackoff     time.Duration // 重试等待时间上限，默认1分钟
+}
+
+// DeadLetter 死信记录（重试耗尽仍处理失败的回调）
+type DeadLetter struct {
+	ID        string           `json:"id"`        // 回调任务ID
+	Request   *CallbackRequest `json:"request"`   // 原始回调请求
+	Attempts  int              `json:"attempts"`  // 已处理次数
+	LastError string           `json:"lastError"` // 最后一次处理的错误信息
+	FailedAt  time.Time        `json:"failedAt"`  // 进入死信的时间
+}
+
+// pendingCallback 持久化的待处理回调
+type pendingCallback struct {
+	ID         string           `json:"id"`
+	Request    *CallbackRequest `json:"request"`
+	ReceivedAt time.Time        `json:"receivedAt"`
+}
+
+// CallbackQueue 回调异步处理队列
+// 回调请求验签通过后先持久化到本地，立即应答平台，再由固定数量的worker异步处理，
+// 处理失败按指数退避重试，重试耗尽后写入死信文件，可通过Redrive重新投递
+type CallbackQueue struct {
+	client  *Client
+	handler CallbackHandler
+	config  CallbackQueueConfig
+
+	jobs    chan *pendingCallback
+	stop    chan struct{}
+	wg      sync.WaitGroup // worker
+	feeder  sync.WaitGroup // 启动时投递积压回调的goroutine
+	mu      sync.RWMutex   // 保护closed及jobs的关闭
+	dlMu    sync.Mutex     // 保护死信文件的读写
+	closed  bool
+	started bool
+	early   sync.Map // Start之前入队的回调ID，避免Start时从持久化目录重复加载
+	seq     uint64
+}
+
+// NewCallbackQueue 创建回调异步处理队列
+func NewCallbackQueue(client *Client, handler CallbackHandler, config *CallbackQueueConfig) (*CallbackQueue, error) {
+	if client == nil {
+		return nil, errors.New("client is required")
+	}
+	if handler == nil {
+		return nil, errors.New("handler is required")
+	}
+	if config == nil || config.PendingDir == "" {
+		return nil, errors.New("pendingDir is required")
+	}
+
+	cfg := *config
+	if cfg.DeadLetterPath == "" {
+		cfg.DeadLetterPath = filepath.Join(cfg.PendingDir, "dead_letter.jsonl")
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Minute
+	}
+
+	if err := os.MkdirAll(cfg.PendingDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create pending dir error: %w", err)
+	}
+
+	return &CallbackQueue{
+		client:  client,
+		handler: handler,
+		config:  cfg,
+		jobs:    make(chan *pendingCallback, cfg.QueueSize),
+		stop:    make(chan struct{}),
+	}, nil
+}
+
+// Start 启动worker，并重新加载上次退出时尚未处理完成的回调
+func (q *CallbackQueue) Start() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrCallbackQueueClosed
+	}
+	if q.started {
+		return nil
+	}
+
+	pending, err := q.loadPending()
+	if err != nil {
+		return err
+	}
+
+	// Start之前入队的回调已在内存队列中，不再重复投递
+	loaded := pending[:0]
+	for _, p := range pending {
+		if _, ok := q.early.Load(p.ID); !ok {
+			loaded = append(loaded, p)
+		}
+	}
+	pending = loaded
+	q.early = sync.Map{}
+
+	q.started = true
+	for i := 0; i < q.config.Workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+
+	// 积压的回调可能超过队列容量，异步投递避免阻塞启动
+	if len(pending) > 0 {
+		q.feeder.Add(1)
+		go func() {
+			defer q.feeder.Done()
+			for _, p := range pending {
+				select {
+				case q.jobs <- p:
+				case <-q.stop:
+					return
+				}
+			}
+		}()
+	}
+
+	return nil
+}
+
+// Enqueue 验证回调签名，持久化后放入处理队列
+// 返回nil即可应答平台成功，业务处理在后台异步进行；Start之前入队的回调在Start后处理
+func (q *CallbackQueue) Enqueue(req *CallbackRequest) error {
+	if err := q.client.VerifyCallbackSign(req); err != nil {
+		return err
+	}
+
+	p := &pendingCallback{
+		ID:         q.nextID(),
+		Request:    req,
+		ReceivedAt: time.Now(),
+	}
+	return q.push(p)
+}
+
+// push 持久化并投递回调任务
+func (q *CallbackQueue) push(p *pendingCallback) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrCallbackQueueClosed
+	}
+
+	if err := q.savePending(p); err != nil {
+		return err
+	}
+	q.markEarly(p.ID)
+
+	select {
+	case q.jobs <- p:
+		return nil
+	default:
+		// 队列已满时不应答成功，交由平台重新推送
+		q.early.Delete(p.ID)
+		q.removePending(p.ID)
+		return ErrCallbackQueueFull
+	}
+}
+
+// pushWait 持久化并投递回调任务，队列已满时等待，直到ctx取消或队列关闭
+func (q *CallbackQueue) pushWait(ctx context.Context, p *pendingCallback) error {
+	q.mu.RLock()
+	if q.closed {
+		q.mu.RUnlock()
+		return ErrCallbackQueueClosed
+	}
+	if err := q.savePending(p); err != nil {
+		q.mu.RUnlock()
+		return err
+	}
+	q.markEarly(p.ID)
+	// 与启动时投递积压回调相同，Close会等待发送结束后再关闭队列
+	q.feeder.Add(1)
+	q.mu.RUnlock()
+	defer q.feeder.Done()
+
+	select {
+	case q.jobs <- p:
+		return nil
+	case <-ctx.Done():
+		q.removePending(p.ID)
+		return ctx.Err()
+	case <-q.stop:
+		q.removePending(p.ID)
+		return ErrCallbackQueueClosed
+	}
+}
+
+// markEarly 记录Start之前入队的回调，调用方需持有mu的读锁
+func (q *CallbackQueue) markEarly(id string) {
+	if !q.started {
+		q.early.Store(id, struct{}{})
+	}
+}
+
+// ServeHTTP 实现http.Handler，可直接注册为平台回调地址
+func (q *CallbackQueue) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req CallbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeCallbackResponse(w, http.StatusBadRequest, "9999", "解析请求失败")
+		return
+	}
+
+	if err := q.Enqueue(&req); err != nil {
+		switch {
+		case errors.Is(err, ErrCallbackQueueFull), errors.Is(err, ErrCallbackQueueClosed):
+			writeCallbackResponse(w, http.StatusServiceUnavailable, "9999", err.Error())
+		default:
+			writeCallbackResponse(w, http.StatusUnauthorized, "9999", err.Error())
+		}
+		return
+	}
+
+	writeCallbackResponse(w, http.StatusOK, "0000", "success")
+}
+
+// Close 停止接收新回调并等待worker退出
+// 正在退避等待重试的回调保留在持久化目录中，下次Start时重新处理
+func (q *CallbackQueue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.stop)
+	q.mu.Unlock()
+
+	q.feeder.Wait()
+	close(q.jobs)
+	q.wg.Wait()
+}
+
+// Redrive 将死信文件中的回调重新投递到处理队列，返回重新投递的数量
+// 死信中的回调在接收时已验签，重新投递时不再验签（时间戳此时通常已过期）。
+// 队列已满时等待worker处理，ctx取消或队列关闭后停止投递，未投递的回调重新追加到死信文件中。
+// 投递前先取出并清空死信文件，投递时不持有死信文件锁，处理失败的worker可以继续写入死信
+func (q *CallbackQueue) Redrive(ctx context.Context) (int, error) {
+	q.dlMu.Lock()
+	letters, err := q.readDeadLetters()
+	if err == nil && len(letters) > 0 {
+		err = q.rewriteDeadLetters(nil)
+	}
+	q.dlMu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, dl := range letters {
+		p := &pendingCallback{ID: dl.ID, Request: dl.Request, ReceivedAt: time.Now()}
+		if err := q.pushWait(ctx, p); err != nil {
+			if werr := q.appendDeadLetters(letters[i:]); werr != nil {
+				return i, errors.Join(err, werr)
+			}
+			return i, err
+		}
+	}
+	return len(letters), nil
+}
+
+// DeadLetters 读取当前死信文件中的全部记录
+func (q *CallbackQueue) DeadLetters() ([]DeadLetter, error) {
+	q.dlMu.Lock()
+	defer q.dlMu.Unlock()
+	return q.readDeadLetters()
+}
+
+// worker 从队列中取出回调并处理
+func (q *CallbackQueue) worker() {
+	defer q.wg.Done()
+	for p := range q.jobs {
+		q.process(p)
+	}
+}
+
+// process 处理单个回调，失败时按指数退避重试
+func (q *CallbackQueue) process(p *pendingCallback) {
+	backoff := q.config.RetryBackoff
+	var lastErr error
+	for attempt := 1; attempt <= q.config.MaxRetries+1; attempt++ {
+		lastErr = q.safeHandle(p.Request)
+		if lastErr == nil {
+			q.removePending(p.ID)
+			return
+		}
+		if attempt > q.config.MaxRetries {
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-q.stop:
+			// 队列关闭，保留持久化文件等待下次启动
+			return
+		}
+		backoff *= 2
+		if backoff > q.config.MaxBackoff {
+			backoff = q.config.MaxBackoff
+		}
+	}
+
+	dl := DeadLetter{
+		ID:        p.ID,
+		Request:   p.Request,
+		Attempts:  q.config.MaxRetries + 1,
+		LastError: lastErr.Error(),
+		FailedAt:  time.Now(),
+	}
+	if err := q.appendDeadLetter(dl); err != nil {
+		// 死信写入失败时保留持久化文件，避免回调丢失
+		return
+	}
+	q.removePending(p.ID)
+}
+
+// safeHandle 调用业务处理函数，并将panic转换为错误
+func (q *CallbackQueue) safeHandle(req *CallbackRequest) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("回调处理panic: %v", r)
+		}
+	}()
+	return q.handler(req)
+}
+
+// nextID 生成回调任务ID
+func (q *CallbackQueue) nextID() string {
+	seq := atomic.AddUint64(&q.seq, 1)
+	return fmt.Sprintf("%d-%06d", time.Now().UnixNano(), seq)
+}
+
+// pendingPath 返回待处理回调的持久化文件路径
+func (q *CallbackQueue) pendingPath(id string) string {
+	return filepath.Join(q.config.PendingDir, id+".json")
+}
+
+// savePending 持久化待处理回调（先写临时文件再重命名，保证文件完整）
+func (q *CallbackQueue) savePending(p *pendingCallback) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("marshal callback error: %w", err)
+	}
+
+	path := q.pendingPath(p.ID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("persist callback error: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("persist callback error: %w", err)
+	}
+	return nil
+}
+
+// removePending 删除已处理完成的回调
+func (q *CallbackQueue) removePending(id string) {
+	_ = os.Remove(q.pendingPath(id))
+}
+
+// loadPending 加载持久化目录中尚未处理的回调，按接收顺序返回
+func (q *CallbackQueue) loadPending() ([]*pendingCallback, error) {
+	entries, err := os.ReadDir(q.config.PendingDir)
+	if err != nil {
+		return nil, fmt.Errorf("read pending dir error: %w", err)
+	}
+
+	var pending []*pendingCallback
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(q.config.PendingDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read pending callback error: %w", err)
+		}
+		var p pendingCallback
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("unmarshal pending callback %s error: %w", entry.Name(), err)
+		}
+		pending = append(pending, &p)
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].ReceivedAt.Before(pending[j].ReceivedAt)
+	})
+	return pending, nil
+}
+
+// appendDeadLetter 追加一条死信记录
+func (q *CallbackQueue) appendDeadLetter(dl DeadLetter) error {
+	return q.appendDeadLetters([]DeadLetter{dl})
+}
+
+// appendDeadLetters 追加多条死信记录
+func (q *CallbackQueue) appendDeadLetters(letters []DeadLetter) error {
+	var buf bytes.Buffer
+	for _, dl := range letters {
+		data, err := json.Marshal(dl)
+		if err != nil {
+			return fmt.Errorf("marshal dead letter error: %w", err)
+		}
+		buf.Write(append(data, '\n'))
+	}
+
+	q.dlMu.Lock()
+	defer q.dlMu.Unlock()
+
+	f, err := os.OpenFile(q.config.DeadLetterPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open dead letter file error: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("write dead letter error: %w", err)
+	}
+	return nil
+}
+
+// readDeadLetters 读取死信文件，调用方需持有dlMu
+func (q *CallbackQueue) readDeadLetters() ([]DeadLetter, error) {
+	f, err := os.Open(q.config.DeadLetterPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open dead letter file error: %w", err)
+	}
+	defer f.Close()
+
+	var letters []DeadLetter
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var dl DeadLetter
+		if err := json.Unmarshal(line, &dl); err != nil {
+			return nil, fmt.Errorf("unmarshal dead letter error: %w", err)
+		}
+		letters = append(letters, dl)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read dead letter file error: %w", err)
+	}
+	return letters, nil
+}
+
+// rewriteDeadLetters 用给定记录覆盖死信文件，调用方需持有dlMu
+func (q *CallbackQueue) rewriteDeadLetters(letters []DeadLetter) error {
+	var builder strings.Builder
+	for _, dl := range letters {
+		data, err := json.Marshal(dl)
+		if err != nil {
+			return fmt.Errorf("marshal dead letter error: %w", err)
+		}
+		builder.Write(data)
+		builder.WriteByte('\n')
+	}
+
+	tmp := q.config.DeadLetterPath + ".tmp"
+	if err := os.WriteFile(tmp, []byte(builder.String()), 0o644); err != nil {
+		return fmt.Errorf("write dead letter file error: %w", err)
+	}
+	if err := os.Rename(tmp, q.config.DeadLetterPath); err != nil {
+		return fmt.Errorf("write dead letter file error: %w", err)
+	}
+	return nil
+}
+
+// writeCallbackResponse 按平台约定的格式应答回调
+func writeCallbackResponse(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"code":    code,
+		"message": message,
+	})
+}
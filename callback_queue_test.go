@@ -0,0 +1,393 @@
+package zczy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitFor 在超时前轮询等待条件成立
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("等待超时")
+}
+
+func newTestCallbackClient() *Client {
+	return &Client{
+		appKey:    "test_app_key",
+		appSecret: "test_app_secret",
+	}
+}
+
+// 测试处理失败后重试成功
+func TestCallbackQueueRetry(t *testing.T) {
+	client := newTestCallbackClient()
+
+	var mu sync.Mutex
+	calls := 0
+	handler := func(req *CallbackRequest) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls < 3 {
+			return errors.New("下游暂不可用")
+		}
+		return nil
+	}
+
+	dir := t.TempDir()
+	queue, err := NewCallbackQueue(client, handler, &CallbackQueueConfig{
+		PendingDir:   dir,
+		Workers:      1,
+		RetryBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewCallbackQueue() 失败: %v", err)
+	}
+	if err := queue.Start(); err != nil {
+		t.Fatalf("Start() 失败: %v", err)
+	}
+	defer queue.Close()
+
+	req := newSignedCallbackRequest(t, client, DelistNotification{OrderID: "102019010101018811"})
+	if err := queue.Enqueue(req); err != nil {
+		t.Fatalf("Enqueue() 失败: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls == 3
+	})
+	waitFor(t, func() bool {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		return len(files) == 0
+	})
+
+	letters, err := queue.DeadLetters()
+	if err != nil {
+		t.Fatalf("DeadLetters() 失败: %v", err)
+	}
+	if len(letters) != 0 {
+		t.Errorf("处理成功后不应产生死信，实际%d条", len(letters))
+	}
+}
+
+// 测试重试耗尽进入死信并重新投递
+func TestCallbackQueueDeadLetterAndRedrive(t *testing.T) {
+	client := newTestCallbackClient()
+
+	var mu sync.Mutex
+	failing := true
+	handled := 0
+	handler := func(req *CallbackRequest) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if failing {
+			return errors.New("ERP同步失败")
+		}
+		handled++
+		return nil
+	}
+
+	queue, err := NewCallbackQueue(client, handler, &CallbackQueueConfig{
+		PendingDir:   t.TempDir(),
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewCallbackQueue() 失败: %v", err)
+	}
+	if err := queue.Start(); err != nil {
+		t.Fatalf("Start() 失败: %v", err)
+	}
+	defer queue.Close()
+
+	req := newSignedCallbackRequest(t, client, DelistNotification{OrderID: "102019010101018811"})
+	if err := queue.Enqueue(req); err != nil {
+		t.Fatalf("Enqueue() 失败: %v", err)
+	}
+
+	var letters []DeadLetter
+	waitFor(t, func() bool {
+		letters, _ = queue.DeadLetters()
+		return len(letters) == 1
+	})
+	if letters[0].Attempts != 3 {
+		t.Errorf("Attempts = %d, want 3", letters[0].Attempts)
+	}
+	if letters[0].LastError != "ERP同步失败" {
+		t.Errorf("LastError = %s", letters[0].LastError)
+	}
+	if letters[0].Request.Data != req.Data {
+		t.Errorf("死信应保留原始回调数据")
+	}
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+
+	n, err := queue.Redrive(context.Background())
+	if err != nil {
+		t.Fatalf("Redrive() 失败: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Redrive() = %d, want 1", n)
+	}
+
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return handled == 1
+	})
+	letters, _ = queue.DeadLetters()
+	if len(letters) != 0 {
+		t.Errorf("重新投递后死信应为空，实际%d条", len(letters))
+	}
+}
+
+// 测试验签失败的回调不会入队
+func TestCallbackQueueRejectsInvalidSign(t *testing.T) {
+	client := newTestCallbackClient()
+	dir := t.TempDir()
+
+	queue, err := NewCallbackQueue(client, func(req *CallbackRequest) error { return nil }, &CallbackQueueConfig{
+		PendingDir: dir,
+	})
+	if err != nil {
+		t.Fatalf("NewCallbackQueue() 失败: %v", err)
+	}
+	defer queue.Close()
+
+	req := newSignedCallbackRequest(t, client, DelistNotification{OrderID: "102019010101018811"})
+	req.Sign = "INVALID_SIGN"
+	if err := queue.Enqueue(req); err == nil {
+		t.Fatal("签名错误时Enqueue()应返回错误")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 0 {
+		t.Errorf("验签失败的回调不应被持久化")
+	}
+}
+
+// 测试重启后处理上次未完成的回调
+func TestCallbackQueueRecoverPending(t *testing.T) {
+	client := newTestCallbackClient()
+	dir := t.TempDir()
+
+	// 第一个队列未启动worker，回调只被持久化
+	first, err := NewCallbackQueue(client, func(req *CallbackRequest) error { return nil }, &CallbackQueueConfig{
+		PendingDir: dir,
+	})
+	if err != nil {
+		t.Fatalf("NewCallbackQueue() 失败: %v", err)
+	}
+	req := newSignedCallbackRequest(t, client, DelistNotification{OrderID: "102019010101018811"})
+	if err := first.Enqueue(req); err != nil {
+		t.Fatalf("Enqueue() 失败: %v", err)
+	}
+	first.Close()
+
+	received := make(chan *CallbackRequest, 1)
+	second, err := NewCallbackQueue(client, func(req *CallbackRequest) error {
+		received <- req
+		return nil
+	}, &CallbackQueueConfig{PendingDir: dir})
+	if err != nil {
+		t.Fatalf("NewCallbackQueue() 失败: %v", err)
+	}
+	if err := second.Start(); err != nil {
+		t.Fatalf("Start() 失败: %v", err)
+	}
+	defer second.Close()
+
+	select {
+	case got := <-received:
+		if got.Data != req.Data {
+			t.Errorf("恢复的回调数据不一致")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("重启后未处理积压回调")
+	}
+}
+
+// 测试HTTP处理器立即应答
+func TestCallbackQueueServeHTTP(t *testing.T) {
+	client := newTestCallbackClient()
+
+	queue, err := NewCallbackQueue(client, func(req *CallbackRequest) error { return nil }, &CallbackQueueConfig{
+		PendingDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("NewCallbackQueue() 失败: %v", err)
+	}
+	if err := queue.Start(); err != nil {
+		t.Fatalf("Start() 失败: %v", err)
+	}
+	defer queue.Close()
+
+	req := newSignedCallbackRequest(t, client, DelistNotification{OrderID: "102019010101018811"})
+	body, _ := json.Marshal(req)
+
+	rec := httptest.NewRecorder()
+	queue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/callback", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("状态码 = %d, want 200, body: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	queue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader("not json")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("状态码 = %d, want 400", rec.Code)
+	}
+}
+
+func TestNewCallbackQueueRequiresPendingDir(t *testing.T) {
+	_, err := NewCallbackQueue(newTestCallbackClient(), func(req *CallbackRequest) error { return nil }, &CallbackQueueConfig{})
+	if err == nil {
+		t.Error("缺少PendingDir时应返回错误")
+	}
+}
+
+// 测试Start之前入队的回调只处理一次
+func TestCallbackQueueEnqueueBeforeStart(t *testing.T) {
+	client := newTestCallbackClient()
+	var mu sync.Mutex
+	handled := 0
+	queue, err := NewCallbackQueue(client, func(req *CallbackRequest) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled++
+		return nil
+	}, &CallbackQueueConfig{PendingDir: t.TempDir(), Workers: 1})
+	if err != nil {
+		t.Fatalf("NewCallbackQueue() 失败: %v", err)
+	}
+	defer queue.Close()
+
+	req := newSignedCallbackRequest(t, client, DelistNotification{OrderID: "102019010101018811"})
+	if err := queue.Enqueue(req); err != nil {
+		t.Fatalf("Enqueue() 失败: %v", err)
+	}
+	if err := queue.Start(); err != nil {
+		t.Fatalf("Start() 失败: %v", err)
+	}
+	waitFor(t, func() bool {
+		files, _ := filepath.Glob(filepath.Join(queue.config.PendingDir, "*.json"))
+		return len(files) == 0
+	})
+	time.Sleep(20 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if handled != 1 {
+		t.Errorf("处理次数 = %d, want 1", handled)
+	}
+}
+
+// 测试重新投递超过队列容量的死信
+func TestCallbackQueueRedriveBeyondQueueSize(t *testing.T) {
+	client := newTestCallbackClient()
+	var mu sync.Mutex
+	handled := 0
+	queue, err := NewCallbackQueue(client, func(req *CallbackRequest) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled++
+		return nil
+	}, &CallbackQueueConfig{PendingDir: t.TempDir(), Workers: 1, QueueSize: 1})
+	if err != nil {
+		t.Fatalf("NewCallbackQueue() 失败: %v", err)
+	}
+
+	req := newSignedCallbackRequest(t, client, DelistNotification{OrderID: "102019010101018811"})
+	for i := 0; i < 5; i++ {
+		if err := queue.appendDeadLetter(DeadLetter{ID: fmt.Sprintf("dl-%d", i), Request: req}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 未启动时队列满后等待，ctx取消后未投递的死信保留
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	n, err := queue.Redrive(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || n != 1 {
+		t.Fatalf("Redrive() = %d, %v, want 1, DeadlineExceeded", n, err)
+	}
+	if letters, _ := queue.DeadLetters(); len(letters) != 4 {
+		t.Fatalf("剩余死信 = %d, want 4", len(letters))
+	}
+
+	if err := queue.Start(); err != nil {
+		t.Fatalf("Start() 失败: %v", err)
+	}
+	defer queue.Close()
+	n, err = queue.Redrive(context.Background())
+	if err != nil || n != 4 {
+		t.Fatalf("Redrive() = %d, %v, want 4", n, err)
+	}
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return handled == 5
+	})
+}
+
+// 测试重新投递超过队列容量的死信，处理仍然失败时不会死锁
+func TestCallbackQueueRedriveFailingHandler(t *testing.T) {
+	client := newTestCallbackClient()
+	queue, err := NewCallbackQueue(client, func(req *CallbackRequest) error {
+		return errors.New("ERP同步失败")
+	}, &CallbackQueueConfig{PendingDir: t.TempDir(), Workers: 1, QueueSize: 1, MaxRetries: -1})
+	if err != nil {
+		t.Fatalf("NewCallbackQueue() 失败: %v", err)
+	}
+
+	req := newSignedCallbackRequest(t, client, DelistNotification{OrderID: "102019010101018811"})
+	for i := 0; i < 5; i++ {
+		if err := queue.appendDeadLetter(DeadLetter{ID: fmt.Sprintf("dl-%d", i), Request: req}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := queue.Start(); err != nil {
+		t.Fatalf("Start() 失败: %v", err)
+	}
+	defer queue.Close()
+
+	done := make(chan struct{})
+	var n int
+	go func() {
+		defer close(done)
+		n, err = queue.Redrive(context.Background())
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Redrive() 未返回")
+	}
+	if err != nil || n != 5 {
+		t.Fatalf("Redrive() = %d, %v, want 5", n, err)
+	}
+	// 处理失败的回调重新写入死信
+	waitFor(t, func() bool {
+		letters, _ := queue.DeadLetters()
+		return len(letters) == 5
+	})
+}
@@ -0,0 +1,350 @@
+package zczy
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// RelayHeaderEventID 转发事件ID请求头，由通知内容计算，平台重复推送同一通知时保持不变，可用于去重
+	RelayHeaderEventID = "X-Relay-Event-Id"
+	// RelayHeaderEventType 转发事件类型请求头
+	RelayHeaderEventType = "X-Relay-Event-Type"
+	// RelayHeaderTimestamp 转发时间戳（秒）请求头
+	RelayHeaderTimestamp = "X-Relay-Timestamp"
+	// RelayHeaderSignature 转发签名请求头
+	RelayHeaderSignature = "X-Relay-Signature"
+)
+
+// RelayTarget 内部转发目标
+type RelayTarget struct {
+	Name string // 目标名称
+	URL  string // 内部webhook地址
+}
+
+// CallbackRelayConfig 回调转发配置
+type CallbackRelayConfig struct {
+	Targets      []RelayTarget // 转发目标（必填）
+	SigningKey   string        // 内部HMAC-SHA256签名密钥（必填），与平台appSecret无关
+	MaxRetries   int           // 每个目标的最大重试次数（不含首次转发），默认3，设为负数表示不重试
+	RetryBackoff time.Duration // 首次重试等待时间，之后按指数递增，默认500毫秒
+	Timeout      int           // HTTP请求超时时间（秒），默认10秒
+	StatusLimit  int           // 内存中保留的转发状态条数，默认1000
+}
+
+// RelayPayload 转发给内部服务的请求体
+type RelayPayload struct {
+	EventID   string          `json:"eventId"`   // 事件ID
+	EventType EventType       `json:"eventType"` // 通知类型
+	OrderID   string          `json:"orderId"`   // 订单号
+	Data      json.RawMessage `json:"data"`      // 解析后的通知数据
+}
+
+// DeliveryStatus 单个目标的转发状态
+type DeliveryStatus struct {
+	EventID    string    // 事件ID
+	EventType  EventType // 通知类型
+	OrderID    string    // 订单号
+	Target     string    // 目标名称
+	Attempts   int       // 已转发次数
+	Delivered  bool      // 是否转发成功
+	StatusCode int       // 最后一次响应状态码
+	LastError  string    // 最后一次失败原因
+	UpdatedAt  time.Time // 状态更新时间
+}
+
+// CallbackRelay 将验签通过的回调转发给多个内部服务
+// 转发时使用内部密钥重新签名，无需向内部服务暴露平台appSecret
+type CallbackRelay struct {
+	client     *Client
+	config     CallbackRelayConfig
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	statuses map[string]*DeliveryStatus
+	order    []string
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewCallbackRelay 创建回调转发器
+func NewCallbackRelay(client *Client, config *CallbackRelayConfig) (*CallbackRelay, error) {
+	if client == nil {
+		return nil, errors.New("client is required")
+	}
+	if config == nil || len(config.Targets) == 0 {
+		return nil, errors.New("targets is required")
+	}
+	if config.SigningKey == "" {
+		return nil, errors.New("signingKey is required")
+	}
+
+	cfg := *config
+	cfg.Targets = append([]RelayTarget(nil), config.Targets...)
+	for i, target := range cfg.Targets {
+		if target.URL == "" {
+			return nil, fmt.Errorf("targets[%d].url is required", i)
+		}
+		if target.Name == "" {
+			cfg.Targets[i].Name = target.URL
+		}
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10
+	}
+	if cfg.StatusLimit <= 0 {
+		cfg.StatusLimit = 1000
+	}
+
+	return &CallbackRelay{
+		client: client,
+		config: cfg,
+		httpClient: &http.Client{
+			Timeout: time.Duration(cfg.Timeout) * time.Second,
+		},
+		statuses: make(map[string]*DeliveryStatus),
+		stop:     make(chan struct{}),
+	}, nil
+}
+
+// Handle 验签并识别通知类型后转发给所有目标，可作为CallbackHandler使用
+// 返回的错误包含所有转发失败的目标
+func (r *CallbackRelay) Handle(req *CallbackRequest) error {
+	event, err := r.client.DecodeCallback(req)
+	if err != nil {
+		return err
+	}
+	return r.Forward("", event)
+}
+
+// Forward 将已解析的通知转发给所有目标，不再验签
+// eventID 用于内部服务去重，为空时按通知内容计算（见 RelayEventID）
+func (r *CallbackRelay) Forward(eventID string, event Event) error {
+	return r.ForwardContext(context.Background(), eventID, event)
+}
+
+// ForwardContext 同 Forward，ctx 取消或转发器关闭后停止重试
+func (r *CallbackRelay) ForwardContext(ctx context.Context, eventID string, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event error: %w", err)
+	}
+	if eventID == "" {
+		eventID = relayEventID(event.EventType(), data)
+	}
+
+	payload := RelayPayload{
+		EventID:   eventID,
+		EventType: event.EventType(),
+		OrderID:   callbackOrderID(string(data)),
+		Data:      data,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal relay payload error: %w", err)
+	}
+
+	errs := make([]error, len(r.config.Targets))
+	var wg sync.WaitGroup
+	for i, target := range r.config.Targets {
+		wg.Add(1)
+		go func(i int, target RelayTarget) {
+			defer wg.Done()
+			errs[i] = r.deliver(ctx, target, &payload, body)
+		}(i, target)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Close 停止等待中的重试，之后的转发失败时不再重试
+func (r *CallbackRelay) Close() {
+	r.closeOnce.Do(func() { close(r.stop) })
+}
+
+// RelayEventID 按通知类型、订单号和通知内容计算事件ID
+// 平台重复推送同一通知时时间戳和签名会变化，但业务数据不变，事件ID保持一致
+func RelayEventID(event Event) (string, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("marshal event error: %w", err)
+	}
+	return relayEventID(event.EventType(), data), nil
+}
+
+// relayEventID 计算事件ID：通知类型-订单号-业务数据SHA256前16位
+func relayEventID(eventType EventType, data []byte) string {
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%s-%s-%s", eventType, callbackOrderID(string(data)), hex.EncodeToString(sum[:8]))
+}
+
+// Deliveries 返回转发状态（按首次转发顺序）
+func (r *CallbackRelay) Deliveries() []DeliveryStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]DeliveryStatus, 0, len(r.order))
+	for _, key := range r.order {
+		result = append(result, *r.statuses[key])
+	}
+	return result
+}
+
+// deliver 向单个目标转发，失败时按指数退避重试
+func (r *CallbackRelay) deliver(ctx context.Context, target RelayTarget, payload *RelayPayload, body []byte) error {
+	status := r.status(target, payload)
+
+	backoff := r.config.RetryBackoff
+	var lastErr error
+	for attempt := 0; attempt <= r.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return fmt.Errorf("转发到%s失败: %w", target.Name, errors.Join(lastErr, ctx.Err()))
+			case <-r.stop:
+				timer.Stop()
+				return fmt.Errorf("转发到%s失败: 转发器已关闭: %w", target.Name, lastErr)
+			}
+			backoff *= 2
+		}
+
+		code, err := r.post(ctx, target, payload, body)
+		r.update(status, func(s *DeliveryStatus) {
+			s.Attempts++
+			s.StatusCode = code
+			s.Delivered = err == nil
+			s.LastError = ""
+			if err != nil {
+				s.LastError = err.Error()
+			}
+		})
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+
+	return fmt.Errorf("转发到%s失败: %w", target.Name, lastErr)
+}
+
+// post 发送一次转发请求
+func (r *CallbackRelay) post(ctx context.Context, target RelayTarget, payload *RelayPayload, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", target.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("create request error: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set(RelayHeaderEventID, payload.EventID)
+	req.Header.Set(RelayHeaderEventType, string(payload.EventType))
+	req.Header.Set(RelayHeaderTimestamp, timestamp)
+	req.Header.Set(RelayHeaderSignature, SignRelayPayload(r.config.SigningKey, timestamp, body))
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("send request error: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// status 获取或创建某事件在某目标上的转发状态
+func (r *CallbackRelay) status(target RelayTarget, payload *RelayPayload) *DeliveryStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := payload.EventID + "\x00" + target.Name
+	if s, ok := r.statuses[key]; ok {
+		return s
+	}
+
+	s := &DeliveryStatus{
+		EventID:   payload.EventID,
+		EventType: payload.EventType,
+		OrderID:   payload.OrderID,
+		Target:    target.Name,
+		UpdatedAt: time.Now(),
+	}
+	r.statuses[key] = s
+	r.order = append(r.order, key)
+
+	// 超出上限时丢弃最早的状态
+	if len(r.order) > r.config.StatusLimit {
+		delete(r.statuses, r.order[0])
+		r.order = r.order[1:]
+	}
+	return s
+}
+
+// update 在锁内更新转发状态
+func (r *CallbackRelay) update(s *DeliveryStatus, fn func(s *DeliveryStatus)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(s)
+	s.UpdatedAt = time.Now()
+}
+
+// SignRelayPayload 计算转发签名：HexEncode(HMAC-SHA256(key, timestamp + "." + body))
+func SignRelayPayload(key, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyRelayRequest 供内部服务验证转发请求的签名
+// maxAge 为允许的时间戳误差，为0时不校验时间戳
+func VerifyRelayRequest(key string, header http.Header, body []byte, maxAge time.Duration) error {
+	timestamp := header.Get(RelayHeaderTimestamp)
+	signature := header.Get(RelayHeaderSignature)
+	if timestamp == "" || signature == "" {
+		return errors.New("缺少转发签名")
+	}
+
+	if maxAge > 0 {
+		ts, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return fmt.Errorf("时间戳格式错误: %v", err)
+		}
+		if abs(time.Now().Unix()-ts) > int64(maxAge/time.Second) {
+			return errors.New("时间戳过期")
+		}
+	}
+
+	expected := SignRelayPayload(key, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.New("转发签名验证失败")
+	}
+	return nil
+}
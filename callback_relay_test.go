@@ -0,0 +1,227 @@
+package zczy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCallbackRelay(t *testing.T) {
+	const signingKey = "internal_key"
+
+	var mu sync.Mutex
+	var received []RelayPayload
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := VerifyRelayRequest(signingKey, r.Header, body, time.Minute); err != nil {
+			t.Errorf("VerifyRelayRequest() error = %v", err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var payload RelayPayload
+		json.Unmarshal(body, &payload)
+		mu.Lock()
+		received = append(received, payload)
+		mu.Unlock()
+	}))
+	defer good.Close()
+
+	// 第一次返回500，重试后成功
+	flakyCalls := 0
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		flakyCalls++
+		if flakyCalls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer flaky.Close()
+
+	client := newTestCallbackClient()
+	relay, err := NewCallbackRelay(client, &CallbackRelayConfig{
+		Targets: []RelayTarget{
+			{Name: "erp", URL: good.URL},
+			{Name: "sms", URL: flaky.URL},
+		},
+		SigningKey:   signingKey,
+		RetryBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewCallbackRelay() error = %v", err)
+	}
+
+	req := newSignedCallbackRequest(t, client, DelistNotification{
+		OrderID:     "102019010101018811",
+		PlateNumber: "苏A12345",
+	})
+	if err := relay.Handle(req); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("erp收到 %d 条转发, want 1", len(received))
+	}
+	if received[0].EventType != EventTypeDelist || received[0].OrderID != "102019010101018811" {
+		t.Errorf("转发内容错误: %+v", received[0])
+	}
+
+	deliveries := relay.Deliveries()
+	if len(deliveries) != 2 {
+		t.Fatalf("转发状态数 = %d, want 2", len(deliveries))
+	}
+	for _, d := range deliveries {
+		if !d.Delivered {
+			t.Errorf("%s 应转发成功: %+v", d.Target, d)
+		}
+		if d.Target == "sms" && d.Attempts != 2 {
+			t.Errorf("sms 转发次数 = %d, want 2", d.Attempts)
+		}
+	}
+}
+
+func TestCallbackRelayFailure(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	client := newTestCallbackClient()
+	relay, err := NewCallbackRelay(client, &CallbackRelayConfig{
+		Targets:      []RelayTarget{{Name: "erp", URL: down.URL}},
+		SigningKey:   "internal_key",
+		MaxRetries:   1,
+		RetryBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewCallbackRelay() error = %v", err)
+	}
+
+	req := newSignedCallbackRequest(t, client, BreachResultNotification{OrderID: "A001", Operation: "1"})
+	if err := relay.Handle(req); err == nil {
+		t.Fatal("目标不可用时Handle()应返回错误")
+	}
+
+	deliveries := relay.Deliveries()
+	if len(deliveries) != 1 || deliveries[0].Delivered || deliveries[0].Attempts != 2 || deliveries[0].StatusCode != http.StatusBadGateway {
+		t.Errorf("转发状态错误: %+v", deliveries)
+	}
+}
+
+func TestVerifyRelayRequest(t *testing.T) {
+	body := []byte(`{"eventId":"1"}`)
+	header := http.Header{}
+	header.Set(RelayHeaderTimestamp, "1737187200")
+	header.Set(RelayHeaderSignature, SignRelayPayload("key", "1737187200", body))
+
+	if err := VerifyRelayRequest("key", header, body, 0); err != nil {
+		t.Errorf("VerifyRelayRequest() error = %v", err)
+	}
+	if err := VerifyRelayRequest("other", header, body, 0); err == nil {
+		t.Error("密钥错误时应返回错误")
+	}
+	if err := VerifyRelayRequest("key", header, body, time.Minute); err == nil {
+		t.Error("时间戳过期时应返回错误")
+	}
+}
+
+func TestCallbackRelayEventIDStable(t *testing.T) {
+	var mu sync.Mutex
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		ids = append(ids, r.Header.Get(RelayHeaderEventID))
+	}))
+	defer server.Close()
+
+	client := newTestCallbackClient()
+	relay, err := NewCallbackRelay(client, &CallbackRelayConfig{
+		Targets:    []RelayTarget{{Name: "erp", URL: server.URL}},
+		SigningKey: "internal_key",
+	})
+	if err != nil {
+		t.Fatalf("NewCallbackRelay() error = %v", err)
+	}
+
+	// 平台重复推送同一通知：时间戳和签名不同，业务数据相同
+	notification := DelistNotification{OrderID: "102019010101018811", PlateNumber: "苏A12345"}
+	first := newSignedCallbackRequest(t, client, notification)
+	second := newSignedCallbackRequest(t, client, notification)
+	second.Timestamp = strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	second.Sign = client.generateCallbackSign(map[string]string{
+		"app_key":   second.AppKey,
+		"timestamp": second.Timestamp,
+		"data":      second.Data,
+	})
+	for _, req := range []*CallbackRequest{first, second} {
+		if err := relay.Handle(req); err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+	}
+
+	if len(ids) != 2 || ids[0] != ids[1] || !strings.HasPrefix(ids[0], "delist-102019010101018811-") {
+		t.Errorf("事件ID = %v, want 两次相同", ids)
+	}
+	if deliveries := relay.Deliveries(); len(deliveries) != 1 || deliveries[0].Attempts != 2 {
+		t.Errorf("转发状态 = %+v", deliveries)
+	}
+}
+
+func TestCallbackRelayRetryControl(t *testing.T) {
+	calls := 0
+	var mu sync.Mutex
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	client := newTestCallbackClient()
+	event := &BreachResultNotification{OrderID: "A001", Operation: "1"}
+
+	// 负数表示不重试
+	relay, err := NewCallbackRelay(client, &CallbackRelayConfig{
+		Targets:    []RelayTarget{{Name: "erp", URL: down.URL}},
+		SigningKey: "internal_key",
+		MaxRetries: -1,
+	})
+	if err != nil {
+		t.Fatalf("NewCallbackRelay() error = %v", err)
+	}
+	if err := relay.Forward("", event); err == nil || calls != 1 {
+		t.Errorf("Forward() = %v, 请求次数 = %d, want 1", err, calls)
+	}
+
+	// ctx 取消后不再等待重试
+	relay, _ = NewCallbackRelay(client, &CallbackRelayConfig{
+		Targets:      []RelayTarget{{Name: "erp", URL: down.URL}},
+		SigningKey:   "internal_key",
+		RetryBackoff: time.Hour,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := relay.ForwardContext(ctx, "", event); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ForwardContext() error = %v, want DeadlineExceeded", err)
+	}
+
+	// 关闭后同样不再等待
+	relay.Close()
+	if err := relay.Forward("", event); err == nil {
+		t.Error("Forward() error = nil")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("重试等待未被中断")
+	}
+}
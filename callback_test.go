@@ -231,3 +231,27 @@ func TestGenerateCallbackSign(t *testing.T) {
 		t.Errorf("相同参数产生不同签名: %s != %s", sign, sign2)
 	}
 }
+
+// newSignedCallbackRequest 构建带有效签名的回调请求
+func newSignedCallbackRequest(t *testing.T, client *Client, data any) *CallbackRequest {
+	t.Helper()
+
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("JSON序列化失败: %v", err)
+	}
+	timestampStr := strconv.FormatInt(time.Now().Unix(), 10)
+
+	params := map[string]string{
+		"app_key":   client.appKey,
+		"timestamp": timestampStr,
+		"data":      string(dataJSON),
+	}
+
+	return &CallbackRequest{
+		AppKey:    client.appKey,
+		Timestamp: timestampStr,
+		Sign:      client.generateCallbackSign(params),
+		Data:      string(dataJSON),
+	}
+}
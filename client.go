@@ -399,15 +399,34 @@ func (c *Client) doGetRequest(params map[string]string) (*Response, error) {
 	return &result, nil
 }
 
+// APIError 平台返回的业务错误（返回码不为0000）
+// 收到该错误说明平台已处理请求并明确拒绝，与网络超时等结果不确定的错误不同
+type APIError struct {
+	Code    string // 返回码
+	Message string // 返回消息
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error: code=%s, message=%s", e.Code, e.Message)
+}
+
 // IsSuccess 判断响应是否成功
 func (r *Response) IsSuccess() bool {
 	return r.Code == "0000"
 }
 
+// Err 响应失败时返回 *APIError，成功时返回nil
+func (r *Response) Err() error {
+	if r.IsSuccess() {
+		return nil
+	}
+	return &APIError{Code: r.Code, Message: r.Message}
+}
+
 // GetData 获取响应数据并反序列化到指定类型
 func (r *Response) GetData(v any) error {
-	if !r.IsSuccess() {
-		return fmt.Errorf("api error: code=%s, message=%s", r.Code, r.Message)
+	if err := r.Err(); err != nil {
+		return err
 	}
 
 	dataBytes, err := json.Marshal(r.Result)
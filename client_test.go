@@ -2,6 +2,8 @@ package zczy
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 )
@@ -383,3 +385,28 @@ func TestBuildRequestParamsWithConsignorId(t *testing.T) {
 		t.Errorf("ConsignorId为空时，请求参数不应包含consignorId字段")
 	}
 }
+
+// newTestAPIClient 创建连接到本地模拟网关的客户端
+// handler 接收接口方法名和业务参数JSON，返回平台响应
+func newTestAPIClient(t *testing.T, handler func(method, params string) *Response) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(handler(r.Form.Get("method"), r.Form.Get("params")))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(&Config{
+		AppKey:    "test_app_key",
+		AppSecret: "test_app_secret",
+		PublicKey: "MFwwDQYJKoZIhvcNAQEBBQADSwAwSAJBALT8QammE81aGfzzmFj0LjHKAOWiyRLESX4fwomlvWr3nVvx4rSzKGz176M/c9UsLQFqJkA0KIk0YxDgS1QG5K8CAwEAAQ==",
+		Gateway:   server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
@@ -0,0 +1,377 @@
+package zczy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// MoneyScale 金额的最大小数位数
+	MoneyScale = 2
+	// QuantityScale 重量/吨位的最大小数位数
+	QuantityScale = 4
+)
+
+// ErrDivisionByZero 除数为零
+var ErrDivisionByZero = errors.New("除数不能为0")
+
+// RoundingMode 舍入模式
+type RoundingMode int
+
+const (
+	// RoundHalfUp 四舍五入
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven 银行家舍入（四舍六入五成双）
+	RoundHalfEven
+	// RoundDown 向零截断
+	RoundDown
+	// RoundUp 远离零进位
+	RoundUp
+)
+
+// Decimal 定点小数，用于金额和吨位的精确计算
+// 零值表示0，所有运算均返回新值，不修改接收者
+type Decimal struct {
+	unscaled *big.Int
+	scale    int32
+}
+
+// NewDecimal 创建定点小数，值为 unscaled × 10^(-scale)
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	if scale < 0 {
+		return Decimal{unscaled: new(big.Int).Mul(big.NewInt(unscaled), pow10(-scale))}
+	}
+	return Decimal{unscaled: big.NewInt(unscaled), scale: scale}
+}
+
+// ParseDecimal 解析十进制数字字符串，例如 "5500"、"-12.35"
+func ParseDecimal(s string) (Decimal, error) {
+	str := strings.TrimSpace(s)
+	neg := false
+	if strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") {
+		neg = str[0] == '-'
+		str = str[1:]
+	}
+
+	intPart, fracPart, hasDot := strings.Cut(str, ".")
+	if intPart == "" || !isDigits(intPart) || (hasDot && (fracPart == "" || !isDigits(fracPart))) {
+		return Decimal{}, fmt.Errorf("无效的数字: %q", s)
+	}
+
+	unscaled, _ := new(big.Int).SetString(intPart+fracPart, 10)
+	if neg {
+		unscaled.Neg(unscaled)
+	}
+	return Decimal{unscaled: unscaled, scale: int32(len(fracPart))}, nil
+}
+
+// MustParseDecimal 解析十进制数字字符串，格式错误时panic，适用于常量
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// int 返回未缩放的整数值，零值Decimal返回0
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// String 返回十进制字符串，保留全部小数位
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+	if d.scale > 0 {
+		if len(digits) <= int(d.scale) {
+			digits = strings.Repeat("0", int(d.scale)-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-int(d.scale)] + "." + digits[len(digits)-int(d.scale):]
+	}
+	if d.int().Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// Scale 返回小数位数
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Sign 返回符号：负数-1，零0，正数1
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero 判断是否为0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp 比较大小：d<o返回-1，相等返回0，d>o返回1
+func (d Decimal) Cmp(o Decimal) int {
+	a, b := align(d, o)
+	return a.Cmp(b)
+}
+
+// Add 加法
+func (d Decimal) Add(o Decimal) Decimal {
+	a, b := align(d, o)
+	return Decimal{unscaled: new(big.Int).Add(a, b), scale: maxScale(d, o)}
+}
+
+// Sub 减法
+func (d Decimal) Sub(o Decimal) Decimal {
+	a, b := align(d, o)
+	return Decimal{unscaled: new(big.Int).Sub(a, b), scale: maxScale(d, o)}
+}
+
+// Mul 乘法，结果的小数位数为两数小数位数之和
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.int(), o.int()), scale: d.scale + o.scale}
+}
+
+// Neg 取相反数
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Div 除法，结果按指定小数位数和舍入模式舍入
+func (d Decimal) Div(o Decimal, scale int32, mode RoundingMode) (Decimal, error) {
+	if o.IsZero() {
+		return Decimal{}, ErrDivisionByZero
+	}
+
+	// 结果 = d.unscaled × 10^(scale + o.scale - d.scale) / o.unscaled
+	num := new(big.Int).Set(d.int())
+	den := new(big.Int).Set(o.int())
+	if exp := scale + o.scale - d.scale; exp >= 0 {
+		num.Mul(num, pow10(exp))
+	} else {
+		den.Mul(den, pow10(-exp))
+	}
+	return Decimal{unscaled: roundQuo(num, den, mode), scale: scale}, nil
+}
+
+// Round 按指定小数位数和舍入模式舍入，结果恰好保留scale位小数
+func (d Decimal) Round(scale int32, mode RoundingMode) Decimal {
+	if d.scale <= scale {
+		return Decimal{unscaled: new(big.Int).Mul(d.int(), pow10(scale-d.scale)), scale: scale}
+	}
+	return Decimal{unscaled: roundQuo(d.int(), pow10(d.scale-scale), mode), scale: scale}
+}
+
+// Min 返回较小值
+func (d Decimal) Min(o Decimal) Decimal {
+	if d.Cmp(o) <= 0 {
+		return d
+	}
+	return o
+}
+
+// Max 返回较大值
+func (d Decimal) Max(o Decimal) Decimal {
+	if d.Cmp(o) >= 0 {
+		return d
+	}
+	return o
+}
+
+// Percent 计算 d × p%，例如金额的30%
+func (d Decimal) Percent(p Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.int(), p.int()), scale: d.scale + p.scale + 2}
+}
+
+// align 将两个数对齐到相同的小数位数
+func align(a, b Decimal) (*big.Int, *big.Int) {
+	switch {
+	case a.scale > b.scale:
+		return a.int(), new(big.Int).Mul(b.int(), pow10(a.scale-b.scale))
+	case a.scale < b.scale:
+		return new(big.Int).Mul(a.int(), pow10(b.scale-a.scale)), b.int()
+	default:
+		return a.int(), b.int()
+	}
+}
+
+// maxScale 返回两数中较大的小数位数
+func maxScale(a, b Decimal) int32 {
+	if a.scale > b.scale {
+		return a.scale
+	}
+	return b.scale
+}
+
+// pow10 返回10的n次方
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// roundQuo 计算 num/den 并按舍入模式舍入
+func roundQuo(num, den *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// 余数与除数的一半比较
+	twiceR := new(big.Int).Abs(r)
+	twiceR.Lsh(twiceR, 1)
+	half := twiceR.Cmp(new(big.Int).Abs(den))
+
+	var away bool
+	switch mode {
+	case RoundDown:
+		away = false
+	case RoundUp:
+		away = true
+	case RoundHalfEven:
+		away = half > 0 || (half == 0 && q.Bit(0) == 1)
+	default:
+		away = half >= 0
+	}
+
+	if away {
+		if (num.Sign() < 0) != (den.Sign() < 0) {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+// Money 金额（元），在JSON中以字符串传输，最多2位小数
+// 以字符串保存以保持与平台报文一致，运算时通过Decimal进行精确计算
+type Money string
+
+// NewMoney 将定点小数按舍入模式转换为金额（保留2位小数）
+func NewMoney(d Decimal, mode RoundingMode) Money {
+	return Money(d.Round(MoneyScale, mode).String())
+}
+
+// ParseMoney 解析并校验金额
+func ParseMoney(s string) (Money, error) {
+	m := Money(strings.TrimSpace(s))
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+	return m, nil
+}
+
+// Decimal 返回金额的定点小数值，空金额视为0
+func (m Money) Decimal() (Decimal, error) {
+	if m == "" {
+		return Decimal{}, nil
+	}
+	return ParseDecimal(string(m))
+}
+
+// Validate 校验金额格式及小数位数，空金额视为未填写，不报错
+func (m Money) Validate() error {
+	return validateScaled(string(m), MoneyScale, "金额")
+}
+
+// MarshalJSON 原样输出字符串，格式和小数位数由 Validate 校验
+// 回调中平台返回的值可能超过约定的小数位数，需要能够原样重新序列化
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(m))
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalNumberString(data)
+	if err != nil {
+		return err
+	}
+	*m = Money(s)
+	return nil
+}
+
+// Quantity 重量/吨位，在JSON中以字符串传输，最多4位小数
+type Quantity string
+
+// NewQuantity 将定点小数按舍入模式转换为重量（保留4位小数）
+func NewQuantity(d Decimal, mode RoundingMode) Quantity {
+	return Quantity(d.Round(QuantityScale, mode).String())
+}
+
+// ParseQuantity 解析并校验重量
+func ParseQuantity(s string) (Quantity, error) {
+	q := Quantity(strings.TrimSpace(s))
+	if err := q.Validate(); err != nil {
+		return "", err
+	}
+	return q, nil
+}
+
+// Decimal 返回重量的定点小数值，空值视为0
+func (q Quantity) Decimal() (Decimal, error) {
+	if q == "" {
+		return Decimal{}, nil
+	}
+	return ParseDecimal(string(q))
+}
+
+// Validate 校验重量格式及小数位数，空值视为未填写，不报错
+func (q Quantity) Validate() error {
+	return validateScaled(string(q), QuantityScale, "重量")
+}
+
+// MarshalJSON 原样输出字符串，格式和小数位数由 Validate 校验
+// 回调中平台返回的值可能超过约定的小数位数，需要能够原样重新序列化
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(q))
+}
+
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalNumberString(data)
+	if err != nil {
+		return err
+	}
+	*q = Quantity(s)
+	return nil
+}
+
+// validateScaled 校验数字格式及最大小数位数
+func validateScaled(s string, scale int32, name string) error {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	d, err := ParseDecimal(s)
+	if err != nil {
+		return fmt.Errorf("%s格式错误: %w", name, err)
+	}
+	if d.scale > scale {
+		return fmt.Errorf("%s小数位数不能超过%d位: %q", name, scale, s)
+	}
+	return nil
+}
+
+// unmarshalNumberString 反序列化字符串或数字形式的数值
+func unmarshalNumberString(data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return "", nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return "", err
+		}
+		return s, nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return "", err
+	}
+	return n.String(), nil
+}
@@ -0,0 +1,166 @@
+package zczy
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"5500", "5500", false},
+		{"5500.00", "5500.00", false},
+		{"-12.35", "-12.35", false},
+		{"0.05", "0.05", false},
+		{"+1.5", "1.5", false},
+		{"", "", true},
+		{"1.", "", true},
+		{".5", "", true},
+		{"1e3", "", true},
+		{"12a", "", true},
+	}
+
+	for _, tt := range tests {
+		d, err := ParseDecimal(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDecimal(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if err == nil && d.String() != tt.want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", tt.input, d.String(), tt.want)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a := MustParseDecimal("0.1")
+	b := MustParseDecimal("0.2")
+
+	if got := a.Add(b).String(); got != "0.3" {
+		t.Errorf("0.1 + 0.2 = %s, want 0.3", got)
+	}
+	if got := a.Sub(b).String(); got != "-0.1" {
+		t.Errorf("0.1 - 0.2 = %s, want -0.1", got)
+	}
+	if got := MustParseDecimal("30.5").Mul(MustParseDecimal("180.25")).String(); got != "5497.625" {
+		t.Errorf("30.5 × 180.25 = %s, want 5497.625", got)
+	}
+	if got := MustParseDecimal("5500").Percent(MustParseDecimal("30")).Round(2, RoundHalfUp).String(); got != "1650.00" {
+		t.Errorf("5500 × 30%% = %s, want 1650.00", got)
+	}
+
+	q, err := MustParseDecimal("10").Div(MustParseDecimal("3"), 2, RoundHalfUp)
+	if err != nil || q.String() != "3.33" {
+		t.Errorf("10 / 3 = %s, %v, want 3.33", q, err)
+	}
+	if _, err := a.Div(Decimal{}, 2, RoundHalfUp); err != ErrDivisionByZero {
+		t.Errorf("除以0应返回ErrDivisionByZero，实际 %v", err)
+	}
+
+	if MustParseDecimal("1.50").Cmp(MustParseDecimal("1.5")) != 0 {
+		t.Error("1.50 应等于 1.5")
+	}
+	var zero Decimal
+	if !zero.IsZero() || zero.String() != "0" {
+		t.Errorf("零值Decimal = %s", zero.String())
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		input string
+		mode  RoundingMode
+		want  string
+	}{
+		{"2.345", RoundHalfUp, "2.35"},
+		{"2.345", RoundHalfEven, "2.34"},
+		{"2.355", RoundHalfEven, "2.36"},
+		{"2.349", RoundDown, "2.34"},
+		{"2.341", RoundUp, "2.35"},
+		{"-2.345", RoundHalfUp, "-2.35"},
+		{"-2.341", RoundDown, "-2.34"},
+		{"-2.341", RoundUp, "-2.35"},
+		{"5500.0000001", RoundHalfUp, "5500.00"},
+		{"12", RoundHalfUp, "12.00"},
+	}
+
+	for _, tt := range tests {
+		if got := MustParseDecimal(tt.input).Round(2, tt.mode).String(); got != tt.want {
+			t.Errorf("Round(%s, %d) = %s, want %s", tt.input, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	if m := NewMoney(MustParseDecimal("5500.0000001"), RoundHalfUp); m != "5500.00" {
+		t.Errorf("NewMoney() = %s, want 5500.00", m)
+	}
+
+	// 小数位数由 Validate 校验，序列化时原样输出
+	if err := Money("5500.0000001").Validate(); err == nil {
+		t.Error("小数位数超过2位的金额 Validate() 应返回错误")
+	}
+	if data, err := json.Marshal(Money("5500.0000001")); err != nil || string(data) != `"5500.0000001"` {
+		t.Errorf("Marshal() = %s, %v", data, err)
+	}
+	if _, err := ParseMoney("abc"); err == nil {
+		t.Error("ParseMoney(abc) 应返回错误")
+	}
+
+	data, err := json.Marshal(ConfirmReceiptRequest{OrderID: "A001", Tonnage: "25.5", SettleMoney: "620.00"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var result map[string]any
+	json.Unmarshal(data, &result)
+	if result["tonnage"] != "25.5" || result["settleMoney"] != "620.00" {
+		t.Errorf("序列化结果错误: %s", data)
+	}
+	if _, ok := result["consignorNoTaxMoney"]; ok {
+		t.Errorf("空金额应被omitempty忽略: %s", data)
+	}
+
+	// 回调中的数值可能是数字或字符串
+	var n DelistNotification
+	if err := json.Unmarshal([]byte(`{"weight":12.5,"safeguardCost":"30.00"}`), &n); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if n.Weight != "12.5" || n.SafeguardCost != "30.00" {
+		t.Errorf("Weight = %s, SafeguardCost = %s", n.Weight, n.SafeguardCost)
+	}
+	weight, err := n.Weight.Decimal()
+	if err != nil || weight.Cmp(MustParseDecimal("12.5")) != 0 {
+		t.Errorf("Weight.Decimal() = %s, %v", weight, err)
+	}
+
+	// 平台返回超过约定小数位数的值时，回调结构体仍可重新序列化（转发、日志、订单存储）
+	body := `{"orderId":"A001","weight":12.34567,"safeguardCost":"30.005"}`
+	n = DelistNotification{}
+	if err := json.Unmarshal([]byte(body), &n); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	data, err = json.Marshal(&n)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+	if fields["weight"] != "12.34567" || fields["safeguardCost"] != "30.005" {
+		t.Errorf("重新序列化结果 = %s", data)
+	}
+}
+
+func TestQuantityValidate(t *testing.T) {
+	if err := Quantity("30.1234").Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := Quantity("30.12345").Validate(); err == nil {
+		t.Error("小数位数超过4位的重量应返回错误")
+	}
+	if q := NewQuantity(MustParseDecimal("1").Add(MustParseDecimal("0.00005")), RoundHalfUp); q != "1.0001" {
+		t.Errorf("NewQuantity() = %s, want 1.0001", q)
+	}
+}
@@ -0,0 +1,293 @@
+package zczy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+var (
+	// ErrDivisionNotFound 未找到行政区划
+	ErrDivisionNotFound = errors.New("未找到行政区划")
+	// ErrDivisionAmbiguous 行政区划名称匹配到多个结果
+	ErrDivisionAmbiguous = errors.New("行政区划名称不唯一")
+	// ErrDivisionNotCovered 目录未收录下级行政区划，无法校验
+	ErrDivisionNotCovered = errors.New("目录未收录下级行政区划")
+)
+
+// DivisionLevel 行政区划级别
+type DivisionLevel int
+
+const (
+	// DivisionProvince 省级
+	DivisionProvince DivisionLevel = iota + 1
+	// DivisionCity 地级
+	DivisionCity
+	// DivisionDistrict 县级
+	DivisionDistrict
+)
+
+// Division 行政区划
+type Division struct {
+	Code     string        `json:"code"`               // 行政区划代码，例如 320106
+	Name     string        `json:"name"`               // 规范名称，例如 鼓楼区
+	Children []*Division   `json:"children,omitempty"` // 下级行政区划，为空表示目录未收录下级
+	Level    DivisionLevel `json:"-"`                  // 级别
+	Parent   *Division     `json:"-"`                  // 上级行政区划，省级为nil
+}
+
+// Child 按名称匹配下级行政区划，规则同 DivisionCatalog.Province
+func (d *Division) Child(name string) (*Division, error) {
+	return matchDivision(d.Children, name)
+}
+
+//go:embed division_data.json
+var divisionData []byte
+
+var (
+	defaultDivisionsOnce sync.Once
+	defaultDivisions     *DivisionCatalog
+)
+
+// DefaultDivisionCatalog 返回内置的行政区划目录
+//
+// 内置目录包含全部省级行政区及其地级市、区县，代码与名称依据 GB/T 2260。
+// 台湾省未收录下级，Resolve 返回 ErrDivisionNotCovered；需要其他数据时使用 LoadDivisionCatalog 加载
+func DefaultDivisionCatalog() *DivisionCatalog {
+	defaultDivisionsOnce.Do(func() {
+		catalog, err := parseDivisionCatalog(divisionData)
+		if err != nil {
+			panic("zczy: 内置行政区划数据错误: " + err.Error())
+		}
+		defaultDivisions = catalog
+	})
+	return defaultDivisions
+}
+
+// DivisionCatalog 行政区划目录，创建后只读，可并发使用
+type DivisionCatalog struct {
+	provinces []*Division
+	byCode    map[string]*Division
+}
+
+// LoadDivisionCatalog 从JSON加载行政区划目录，格式与内置数据相同：
+//
+//	[{"code":"320000","name":"江苏省","children":[{"code":"320100","name":"南京市","children":[...]}]}]
+//
+// 不设区县的城市（如东莞市）和省直辖的县级行政区（如仙桃市）以同名同代码的下级表示，按代码查询时返回上级
+func LoadDivisionCatalog(r io.Reader) (*DivisionCatalog, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("读取行政区划数据失败: %w", err)
+	}
+	return parseDivisionCatalog(data)
+}
+
+// parseDivisionCatalog 解析行政区划数据并建立索引
+func parseDivisionCatalog(data []byte) (*DivisionCatalog, error) {
+	var provinces []*Division
+	if err := json.Unmarshal(data, &provinces); err != nil {
+		return nil, fmt.Errorf("解析行政区划数据失败: %w", err)
+	}
+
+	c := &DivisionCatalog{provinces: provinces, byCode: make(map[string]*Division)}
+	var index func(list []*Division, parent *Division, level DivisionLevel) error
+	index = func(list []*Division, parent *Division, level DivisionLevel) error {
+		for _, d := range list {
+			if d.Code == "" || d.Name == "" {
+				return fmt.Errorf("行政区划代码和名称不能为空: %+v", *d)
+			}
+			if level > DivisionDistrict {
+				return fmt.Errorf("行政区划%s(%s)超过三级", d.Name, d.Code)
+			}
+			sameAsParent := parent != nil && d.Code == parent.Code && d.Name == parent.Name
+			if _, ok := c.byCode[d.Code]; ok && !sameAsParent {
+				return fmt.Errorf("行政区划代码重复: %s", d.Code)
+			}
+			d.Level = level
+			d.Parent = parent
+			if !sameAsParent {
+				c.byCode[d.Code] = d
+			}
+			if err := index(d.Children, d, level+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := index(provinces, nil, DivisionProvince); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Lookup 按行政区划代码查询
+func (c *DivisionCatalog) Lookup(code string) (*Division, bool) {
+	d, ok := c.byCode[strings.TrimSpace(code)]
+	return d, ok
+}
+
+// Provinces 返回全部省级行政区划
+func (c *DivisionCatalog) Provinces() []*Division {
+	return append([]*Division(nil), c.provinces...)
+}
+
+// Province 按名称匹配省级行政区划
+//
+// 依次尝试：完全相同；去掉"省""市""自治区"等后缀后相同（"江苏"匹配"江苏省"，"广西"匹配"广西壮族自治区"）；
+// 规范名称以输入开头且唯一。匹配到多个结果时返回 ErrDivisionAmbiguous
+func (c *DivisionCatalog) Province(name string) (*Division, error) {
+	return matchDivision(c.provinces, name)
+}
+
+// DivisionPath 省、市、区县三级行政区划
+type DivisionPath struct {
+	Province *Division
+	City     *Division // 目录未收录该省的地级市时为nil
+	District *Division // 目录未收录该市的区县时为nil
+}
+
+// DivisionError 行政区划匹配失败
+type DivisionError struct {
+	Level  DivisionLevel // 匹配失败的级别
+	Name   string        // 输入的名称
+	Parent *Division     // 上级行政区划，省级为nil
+	Err    error         // ErrDivisionNotFound、ErrDivisionAmbiguous 或 ErrDivisionNotCovered
+}
+
+func (e *DivisionError) Error() string {
+	if e.Parent == nil {
+		return fmt.Sprintf("省 %q: %v", e.Name, e.Err)
+	}
+	level := "市"
+	if e.Level == DivisionDistrict {
+		level = "区县"
+	}
+	if errors.Is(e.Err, ErrDivisionNotCovered) {
+		return fmt.Sprintf("%s %q 无法校验: %v（%s）", level, e.Name, e.Err, e.Parent.Name)
+	}
+	return fmt.Sprintf("%s %q 不属于%s: %v", level, e.Name, e.Parent.Name, e.Err)
+}
+
+func (e *DivisionError) Unwrap() error {
+	return e.Err
+}
+
+// Resolve 按名称匹配省、市、区县，并校验下级属于上级，匹配失败时返回 *DivisionError
+//
+// 某一级在目录中没有收录下级时，返回已匹配的部分（未校验的字段为nil）和 Err 为 ErrDivisionNotCovered 的 *DivisionError，
+// 调用方可以用 errors.Is(err, ErrDivisionNotCovered) 判断后决定是否接受未校验的下级名称
+func (c *DivisionCatalog) Resolve(province, city, district string) (*DivisionPath, error) {
+	path := &DivisionPath{}
+	var err error
+	if path.Province, err = c.Province(province); err != nil {
+		return nil, &DivisionError{Level: DivisionProvince, Name: province, Err: err}
+	}
+	if len(path.Province.Children) == 0 {
+		return path, &DivisionError{Level: DivisionCity, Name: city, Parent: path.Province, Err: ErrDivisionNotCovered}
+	}
+	if path.City, err = path.Province.Child(city); err != nil {
+		return nil, &DivisionError{Level: DivisionCity, Name: city, Parent: path.Province, Err: err}
+	}
+	if len(path.City.Children) == 0 {
+		return path, &DivisionError{Level: DivisionDistrict, Name: district, Parent: path.City, Err: ErrDivisionNotCovered}
+	}
+	if path.District, err = path.City.Child(district); err != nil {
+		return nil, &DivisionError{Level: DivisionDistrict, Name: district, Parent: path.City, Err: err}
+	}
+	return path, nil
+}
+
+// NormalizeAddress 将收发货地址的省、市、区县替换为规范名称
+// 任一地址无法匹配时不修改该地址，返回 ValidationErrors，路径为对应的JSON字段名，例如 deliverDis；
+// 目录未收录下级时替换已匹配的部分，并在未校验的字段上记录错误，errors.Is(err, ErrDivisionNotCovered) 为true
+func (c *DivisionCatalog) NormalizeAddress(info *OrderAddressInfo) error {
+	v := &validator{}
+	c.normalize(v, "despatch", &info.DespatchPro, &info.DespatchCity, &info.DespatchDis)
+	c.normalize(v, "deliver", &info.DeliverPro, &info.DeliverCity, &info.DeliverDis)
+	return v.err()
+}
+
+// normalize 匹配并替换一组省、市、区县名称，prefix 为 despatch 或 deliver
+func (c *DivisionCatalog) normalize(v *validator, prefix string, province, city, district *string) {
+	path, err := c.Resolve(*province, *city, *district)
+	if err != nil {
+		var divErr *DivisionError
+		errors.As(err, &divErr)
+		field := map[DivisionLevel]string{DivisionProvince: "Pro", DivisionCity: "City", DivisionDistrict: "Dis"}[divErr.Level]
+		v.errs = append(v.errs, &FieldError{Path: prefix + field, Message: err.Error(), Err: err})
+		if path == nil {
+			return
+		}
+	}
+
+	*province = path.Province.Name
+	if path.City != nil {
+		*city = path.City.Name
+	}
+	if path.District != nil {
+		*district = path.District.Name
+	}
+}
+
+// divisionSuffixes 匹配时可省略的名称后缀，较长的在前
+var divisionSuffixes = []string{
+	"维吾尔自治区", "壮族自治区", "回族自治区", "特别行政区",
+	"自治区", "自治州", "自治县", "新区", "地区",
+	"省", "市", "区", "县", "盟", "旗",
+}
+
+// divisionShortName 去掉名称后缀，去掉后为空时返回原名称
+func divisionShortName(name string) string {
+	for _, suffix := range divisionSuffixes {
+		if short := strings.TrimSuffix(name, suffix); short != name && short != "" {
+			return short
+		}
+	}
+	return name
+}
+
+// matchDivision 在同级行政区划中按名称匹配
+func matchDivision(list []*Division, name string) (*Division, error) {
+	name = strings.Join(strings.Fields(name), "")
+	if name == "" {
+		return nil, fmt.Errorf("%w: 名称为空", ErrDivisionNotFound)
+	}
+	for _, d := range list {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+
+	short := divisionShortName(name)
+	matchers := []func(d *Division) bool{
+		func(d *Division) bool { return divisionShortName(d.Name) == short },
+		func(d *Division) bool { return utf8.RuneCountInString(name) >= 2 && strings.HasPrefix(d.Name, name) },
+	}
+	for _, match := range matchers {
+		var found []*Division
+		for _, d := range list {
+			if match(d) {
+				found = append(found, d)
+			}
+		}
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], nil
+		default:
+			names := make([]string, len(found))
+			for i, d := range found {
+				names[i] = d.Name
+			}
+			return nil, fmt.Errorf("%w: %q 可能是 %s", ErrDivisionAmbiguous, name, strings.Join(names, "、"))
+		}
+	}
+	return nil, ErrDivisionNotFound
+}
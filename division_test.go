@@ -0,0 +1,219 @@
+package zczy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDefaultDivisionCatalog(t *testing.T) {
+	c := DefaultDivisionCatalog()
+	if n := len(c.Provinces()); n != 34 {
+		t.Errorf("省级行政区数量 = %d, want 34", n)
+	}
+
+	d, ok := c.Lookup("320106")
+	if !ok {
+		t.Fatal("Lookup(320106) 未找到")
+	}
+	if d.Name != "鼓楼区" || d.Level != DivisionDistrict || d.Parent.Name != "南京市" || d.Parent.Parent.Name != "江苏省" {
+		t.Errorf("Lookup(320106) = %s %d %s", d.Name, d.Level, d.Parent.Name)
+	}
+
+	// 每个省份都收录了地级市，每个地级市都收录了区县（台湾省除外）
+	for _, p := range c.Provinces() {
+		if len(p.Children) == 0 && p.Name != "台湾省" {
+			t.Errorf("%s 未收录地级市", p.Name)
+		}
+		for _, city := range p.Children {
+			if len(city.Children) == 0 {
+				t.Errorf("%s%s 未收录区县", p.Name, city.Name)
+			}
+		}
+	}
+
+	// 不设区县的城市按代码查询返回城市
+	if d, ok := c.Lookup("441900"); !ok || d.Name != "东莞市" || d.Level != DivisionCity {
+		t.Errorf("Lookup(441900) = %v, %v", d, ok)
+	}
+}
+
+func TestDivisionCatalogProvince(t *testing.T) {
+	c := DefaultDivisionCatalog()
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"江苏省", "江苏省"},
+		{"江苏", "江苏省"},
+		{" 江 苏 ", "江苏省"},
+		{"广西", "广西壮族自治区"},
+		{"广西自治区", "广西壮族自治区"},
+		{"内蒙古", "内蒙古自治区"},
+		{"新疆", "新疆维吾尔自治区"},
+		{"上海", "上海市"},
+		{"香港", "香港特别行政区"},
+	}
+	for _, tt := range tests {
+		d, err := c.Province(tt.input)
+		if err != nil {
+			t.Errorf("Province(%q) error = %v", tt.input, err)
+			continue
+		}
+		if d.Name != tt.want {
+			t.Errorf("Province(%q) = %s, want %s", tt.input, d.Name, tt.want)
+		}
+	}
+
+	if _, err := c.Province("火星"); !errors.Is(err, ErrDivisionNotFound) {
+		t.Errorf("Province(火星) error = %v, want ErrDivisionNotFound", err)
+	}
+	if _, err := c.Province(""); !errors.Is(err, ErrDivisionNotFound) {
+		t.Errorf("Province(\"\") error = %v, want ErrDivisionNotFound", err)
+	}
+}
+
+func TestDivisionMatchAmbiguous(t *testing.T) {
+	list := []*Division{{Code: "1", Name: "新华区"}, {Code: "2", Name: "新华县"}}
+	if _, err := matchDivision(list, "新华"); !errors.Is(err, ErrDivisionAmbiguous) {
+		t.Errorf("matchDivision(新华) error = %v, want ErrDivisionAmbiguous", err)
+	}
+	if d, err := matchDivision(list, "新华县"); err != nil || d.Code != "2" {
+		t.Errorf("matchDivision(新华县) = %v, %v", d, err)
+	}
+}
+
+func TestDivisionCatalogResolve(t *testing.T) {
+	c := DefaultDivisionCatalog()
+
+	path, err := c.Resolve("上海", "上海", "浦东")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if path.Province.Name != "上海市" || path.City.Name != "上海市" || path.District.Code != "310115" {
+		t.Errorf("Resolve() = %s %s %s", path.Province.Name, path.City.Name, path.District.Name)
+	}
+
+	// 鼓楼区不属于苏州市
+	_, err = c.Resolve("江苏", "苏州", "鼓楼区")
+	var divErr *DivisionError
+	if !errors.As(err, &divErr) || divErr.Level != DivisionDistrict || divErr.Parent.Name != "苏州市" {
+		t.Fatalf("Resolve() error = %v, want 区县 DivisionError", err)
+	}
+	if !errors.Is(err, ErrDivisionNotFound) {
+		t.Errorf("Resolve() error = %v, want ErrDivisionNotFound", err)
+	}
+
+	tests := []struct {
+		province, city, district string
+		want                     string
+	}{
+		{"江苏省", "无锡", "梁溪区", "320213"},
+		{"四川", "成都", "武侯区", "510107"},
+		{"山东", "济南", "历下", "370102"},
+		{"新疆", "伊犁", "伊宁市", "654002"},
+		// 不设区县的城市和省直辖的县级行政区，区县与城市同名同代码
+		{"广东", "东莞", "东莞", "441900"},
+		{"湖北", "仙桃", "仙桃市", "429004"},
+	}
+	for _, tt := range tests {
+		path, err := c.Resolve(tt.province, tt.city, tt.district)
+		if err != nil {
+			t.Errorf("Resolve(%s, %s, %s) error = %v", tt.province, tt.city, tt.district, err)
+			continue
+		}
+		if path.District.Code != tt.want {
+			t.Errorf("Resolve(%s, %s, %s) = %s, want %s", tt.province, tt.city, tt.district, path.District.Code, tt.want)
+		}
+	}
+
+	// 临夏市、临夏县同属临夏回族自治州
+	if _, err := c.Resolve("甘肃", "临夏", "临夏"); !errors.Is(err, ErrDivisionAmbiguous) {
+		t.Errorf("Resolve(临夏) error = %v, want ErrDivisionAmbiguous", err)
+	}
+
+	// 未收录地级市的省份返回已匹配部分和 ErrDivisionNotCovered
+	path, err = c.Resolve("台湾", "台北", "中正区")
+	if !errors.As(err, &divErr) || divErr.Level != DivisionCity || !errors.Is(err, ErrDivisionNotCovered) {
+		t.Fatalf("Resolve() error = %v, want 市 ErrDivisionNotCovered", err)
+	}
+	if path == nil || path.Province.Name != "台湾省" || path.City != nil || path.District != nil {
+		t.Errorf("Resolve() = %+v", path)
+	}
+
+	// 自定义目录中未收录区县的城市
+	custom, err := LoadDivisionCatalog(strings.NewReader(`[{"code":"1","name":"甲省","children":[{"code":"2","name":"乙市"}]}]`))
+	if err != nil {
+		t.Fatalf("LoadDivisionCatalog() error = %v", err)
+	}
+	path, err = custom.Resolve("甲省", "乙市", "丙区")
+	if !errors.As(err, &divErr) || divErr.Level != DivisionDistrict || !errors.Is(err, ErrDivisionNotCovered) {
+		t.Fatalf("Resolve() error = %v, want 区县 ErrDivisionNotCovered", err)
+	}
+	if path == nil || path.City.Name != "乙市" || path.District != nil {
+		t.Errorf("Resolve() = %+v", path)
+	}
+}
+
+func TestDivisionCatalogNormalizeAddress(t *testing.T) {
+	c := DefaultDivisionCatalog()
+
+	info := &OrderAddressInfo{
+		DespatchPro: "江苏", DespatchCity: "南京", DespatchDis: "鼓楼",
+		DeliverPro: "广东省", DeliverCity: "深圳市", DeliverDis: "南山",
+	}
+	if err := c.NormalizeAddress(info); err != nil {
+		t.Fatalf("NormalizeAddress() error = %v", err)
+	}
+	got := []string{info.DespatchPro, info.DespatchCity, info.DespatchDis, info.DeliverPro, info.DeliverCity, info.DeliverDis}
+	if want := "江苏省,南京市,鼓楼区,广东省,深圳市,南山区"; strings.Join(got, ",") != want {
+		t.Errorf("NormalizeAddress() = %v, want %s", got, want)
+	}
+
+	info = &OrderAddressInfo{
+		DespatchPro: "江苏", DespatchCity: "南京", DespatchDis: "鼓楼",
+		DeliverPro: "广东", DeliverCity: "太原", DeliverDis: "小店区",
+	}
+	err := c.NormalizeAddress(info)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || errs.Field("deliverCity") == nil {
+		t.Fatalf("NormalizeAddress() error = %v, want deliverCity 错误", err)
+	}
+	if info.DespatchPro != "江苏省" || info.DeliverPro != "广东" {
+		t.Errorf("只应修改匹配成功的地址，实际 %s %s", info.DespatchPro, info.DeliverPro)
+	}
+
+	// 未收录下级的省份：替换省名，市不能通过校验
+	info = &OrderAddressInfo{
+		DespatchPro: "台湾", DespatchCity: "台北", DespatchDis: "中正区",
+		DeliverPro: "上海", DeliverCity: "上海", DeliverDis: "浦东",
+	}
+	err = c.NormalizeAddress(info)
+	if !errors.As(err, &errs) || errs.Field("despatchCity") == nil || len(errs) != 1 {
+		t.Fatalf("NormalizeAddress() error = %v, want despatchCity 错误", err)
+	}
+	if !errors.Is(err, ErrDivisionNotCovered) {
+		t.Errorf("NormalizeAddress() error = %v, want ErrDivisionNotCovered", err)
+	}
+	if info.DespatchPro != "台湾省" || info.DespatchCity != "台北" || info.DeliverDis != "浦东新区" {
+		t.Errorf("NormalizeAddress() = %+v", info)
+	}
+}
+
+func TestLoadDivisionCatalog(t *testing.T) {
+	data := `[{"code":"1","name":"甲省","children":[{"code":"2","name":"乙市"}]}]`
+	c, err := LoadDivisionCatalog(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("LoadDivisionCatalog() error = %v", err)
+	}
+	if d, ok := c.Lookup("2"); !ok || d.Level != DivisionCity {
+		t.Errorf("Lookup(2) = %v, %v", d, ok)
+	}
+
+	if _, err := LoadDivisionCatalog(strings.NewReader(`[{"code":"1","name":"甲省"},{"code":"1","name":"丙省"}]`)); err == nil {
+		t.Error("代码重复时应返回错误")
+	}
+	if _, err := LoadDivisionCatalog(strings.NewReader(`[{"code":"1","name":"甲省","children":[{"code":"2","name":"乙市","children":[{"code":"2","name":"丁区"}]}]}]`)); err == nil {
+		t.Error("下级与上级代码相同但名称不同时应返回错误")
+	}
+}
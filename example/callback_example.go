@@ -71,7 +71,7 @@ func handleDelistCallback(client *zczy.Client, w http.ResponseWriter, r *http.Re
 	// 处理业务逻辑
 	fmt.Printf("=== 收到摘单通知 ===\n")
 	fmt.Printf("订单号: %s\n", notification.OrderID)
-	fmt.Printf("订单类型: %s\n", getOrderModelDesc(notification.OrderModel))
+	fmt.Printf("订单类型: %s\n", notification.OrderModel)
 	fmt.Printf("货主名称: %s (%s)\n", notification.ConsignorUserName, notification.ConsignorMobile)
 	fmt.Printf("承运方: %s (%s)\n", notification.CarrierName, notification.CarrierMobile)
 	fmt.Printf("司机: %s (%s)\n", notification.DriverUserName, notification.DriverMobile)
@@ -79,7 +79,7 @@ func handleDelistCallback(client *zczy.Client, w http.ResponseWriter, r *http.Re
 	fmt.Printf("货物名称: %s\n", notification.CargoName)
 	fmt.Printf("摘单吨位: %s\n", notification.Weight)
 	fmt.Printf("摘牌时间: %s\n", notification.DelistTime)
-	fmt.Printf("承运状态: %s\n", getConsignorStateDesc(notification.ConsignorState))
+	fmt.Printf("承运状态: %s\n", notification.ConsignorState.Description())
 
 	// 返回成功响应
 	sendSuccessResponse(w)
@@ -115,7 +115,7 @@ func handleBreachResultCallback(client *zczy.Client, w http.ResponseWriter, r *h
 	// 处理业务逻辑
 	fmt.Printf("=== 收到违约结果通知 ===\n")
 	fmt.Printf("订单号: %s\n", notification.OrderID)
-	fmt.Printf("运单状态: %s\n", notification.ConsignorState)
+	fmt.Printf("运单状态: %s\n", notification.ConsignorState.Description())
 	fmt.Printf("操作结果: %s\n", getOperationDesc(notification.Operation))
 	fmt.Printf("违约金额: %s\n", notification.ConsignorAmount)
 	fmt.Printf("运单是否终止: %s\n", getIsStopDesc(notification.IsStop))
@@ -135,34 +135,6 @@ func sendSuccessResponse(w http.ResponseWriter) {
 	})
 }
 
-// getOrderModelDesc 获取订单类型描述
-func getOrderModelDesc(model string) string {
-	switch model {
-	case "0":
-		return "抢单"
-	case "1":
-		return "竞价"
-	default:
-		return "未知"
-	}
-}
-
-// getConsignorStateDesc 获取承运状态描述
-func getConsignorStateDesc(state string) string {
-	switch state {
-	case "5":
-		return "摘单"
-	case "6":
-		return "确认发货"
-	case "7":
-		return "确认收货"
-	case "8":
-		return "已终止"
-	default:
-		return "未知"
-	}
-}
-
 // getOperationDesc 获取操作描述
 func getOperationDesc(operation string) string {
 	switch operation {
@@ -39,8 +39,8 @@ func OrderCreateExample() {
 func createOrderWithFullBuilder(client *zczy.Client) {
 	// 使用构建器创建订单信息
 	orderInfo := zczy.NewOrderInfoBuilder().
-		SetOrderModel("抢单").
-		SetFreightType("单价").
+		SetOrderModel(zczy.OrderModelGrab).
+		SetFreightType(zczy.FreightTypeUnitPrice).
 		SetSelfComment("TEST20250117001").
 		SetContact("赵先生", "13800000000").
 		SetVehicle("高栏车", "12").
@@ -62,7 +62,7 @@ func createOrderWithFullBuilder(client *zczy.Client) {
 	cargo := zczy.NewCargoInfoBuilder().
 		SetCargoName("钢材").
 		SetCargoVersion("Q235").
-		SetCargoCategory("重货").
+		SetCargoCategory(zczy.CargoCategoryHeavy).
 		SetWeight("30.0").
 		SetDimensions("6", "2", "1.5").
 		SetPack("捆").
@@ -97,8 +97,8 @@ func createOrderWithFullBuilder(client *zczy.Client) {
 func createOrderWithPartialBuilder(client *zczy.Client) {
 	// 订单信息使用构建器
 	orderInfo := zczy.NewOrderInfoBuilder().
-		SetOrderModel("抢单").
-		SetFreightType("单价").
+		SetOrderModel(zczy.OrderModelGrab).
+		SetFreightType(zczy.FreightTypeUnitPrice).
 		SetSelfComment("TEST20250117002").
 		SetContact("张先生", "13800000010").
 		SetVehicle("厢式车", "17").
@@ -116,7 +116,7 @@ func createOrderWithPartialBuilder(client *zczy.Client) {
 	cargoList := []zczy.CargoInfo{
 		{
 			CargoName:     "煤炭",
-			CargoCategory: "重货",
+			CargoCategory: zczy.CargoCategoryHeavy,
 			Weight:        "25.0",
 			Pack:          "散装",
 		},
@@ -150,8 +150,8 @@ func createOrderWithPartialBuilder(client *zczy.Client) {
 // 示例3：创建多货物订单
 func createMultiCargoOrder(client *zczy.Client) {
 	orderInfo := zczy.NewOrderInfoBuilder().
-		SetOrderModel("抢单").
-		SetFreightType("包车价").
+		SetOrderModel(zczy.OrderModelGrab).
+		SetFreightType(zczy.FreightTypeWholeTruck).
 		SetSelfComment("MULTI20250117001").
 		SetContact("陈先生", "13800000020").
 		SetVehicle("厢式车", "17").
@@ -171,7 +171,7 @@ func createMultiCargoOrder(client *zczy.Client) {
 	cargo1 := zczy.NewCargoInfoBuilder().
 		SetCargoName("电子产品").
 		SetCargoVersion("笔记本电脑").
-		SetCargoCategory("泡货").
+		SetCargoCategory(zczy.CargoCategoryLight).
 		SetWeight("2.5").
 		SetDimensions("1.2", "0.8", "1.0").
 		SetPack("纸箱").
@@ -180,7 +180,7 @@ func createMultiCargoOrder(client *zczy.Client) {
 	cargo2 := zczy.NewCargoInfoBuilder().
 		SetCargoName("五金配件").
 		SetCargoVersion("标准件").
-		SetCargoCategory("重货").
+		SetCargoCategory(zczy.CargoCategoryHeavy).
 		SetWeight("5.0").
 		SetDimensions("0.8", "0.6", "0.5").
 		SetPack("木箱").
@@ -188,7 +188,7 @@ func createMultiCargoOrder(client *zczy.Client) {
 
 	cargo3 := zczy.NewCargoInfoBuilder().
 		SetCargoName("办公用品").
-		SetCargoCategory("泡货").
+		SetCargoCategory(zczy.CargoCategoryLight).
 		SetWeight("1.2").
 		SetPack("纸箱").
 		Build()
@@ -0,0 +1,183 @@
+package zczy
+
+import "fmt"
+
+// FreightBreakdown 运费明细，金额均保留2位小数
+type FreightBreakdown struct {
+	FreightType         FreightType // 费用类型
+	Weight              Quantity    // 货物总重量
+	UnitPrice           Money       // 每吨运费（仅单价订单）
+	UnitNoTaxPrice      Money       // 承运方每吨预估到手价（仅单价订单）
+	Freight             Money       // 总运费
+	ConsignorNoTaxMoney Money       // 承运方预估到手总额
+	Advance             Money       // 预付金额
+	Balance             Money       // 尾款 = 总运费 - 预付金额
+	Oil                 Money       // 油品金额
+	Gas                 Money       // 气品金额
+	Cash                Money       // 现金 = 总运费 - 油品 - 气品
+}
+
+// Fill 将计算得到的运费和承运方预估到手价填入订单信息
+// 单价订单填入每吨价格，包车价订单填入整车价格
+func (b *FreightBreakdown) Fill(info *OrderInfo) {
+	if b.FreightType == FreightTypeUnitPrice {
+		info.TotalAmount = b.UnitPrice
+		info.ConsignorNoTaxMoney = b.UnitNoTaxPrice
+		return
+	}
+	info.TotalAmount = b.Freight
+	info.ConsignorNoTaxMoney = b.ConsignorNoTaxMoney
+}
+
+// FreightCalculator 运费计算器
+//
+// 单价订单的 TotalAmount/ConsignorNoTaxMoney 为每吨价格，总运费 = 单价 × 货物总重量；
+// 包车价订单的 TotalAmount/ConsignorNoTaxMoney 为整车价格。
+// 运费与承运方预估到手价按 ServiceRate 换算：到手价 = 运费 × (100 - ServiceRate)%，只填写一项时自动推算另一项
+type FreightCalculator struct {
+	ServiceRate string       // 平台服务费及税费比例（百分比），例如 "6.5"，为空表示0
+	Rounding    RoundingMode // 金额舍入模式
+}
+
+// NewFreightCalculator 创建运费计算器
+func NewFreightCalculator(serviceRate string, rounding RoundingMode) (*FreightCalculator, error) {
+	c := &FreightCalculator{ServiceRate: serviceRate, Rounding: rounding}
+	if _, err := c.serviceRate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// serviceRate 解析服务费比例，直接构造的计算器同样在计算时校验
+func (c *FreightCalculator) serviceRate() (Decimal, error) {
+	if c.ServiceRate == "" {
+		return NewDecimal(0, 0), nil
+	}
+	rate, err := ParseDecimal(c.ServiceRate)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("服务费比例格式错误: %w", err)
+	}
+	if rate.Sign() < 0 || rate.Cmp(NewDecimal(100, 0)) >= 0 {
+		return Decimal{}, fmt.Errorf("服务费比例必须在0到100之间: %s", c.ServiceRate)
+	}
+	return rate, nil
+}
+
+// CalculateRequest 计算创建订单请求的运费明细
+func (c *FreightCalculator) CalculateRequest(req *CreateOrderRequest) (*FreightBreakdown, error) {
+	return c.Calculate(&req.OrderInfo, req.CargoList)
+}
+
+// Calculate 根据订单信息和货物列表计算运费明细
+func (c *FreightCalculator) Calculate(info *OrderInfo, cargoList []CargoInfo) (*FreightBreakdown, error) {
+	freightType, err := ParseFreightType(string(info.FreightType))
+	if err != nil {
+		return nil, err
+	}
+
+	var weight Decimal
+	for i, cargo := range cargoList {
+		w, err := cargo.Weight.Decimal()
+		if err != nil {
+			return nil, fmt.Errorf("cargoList[%d].weight: %w", i, err)
+		}
+		weight = weight.Add(w)
+	}
+
+	price, noTaxPrice, err := c.prices(info)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &FreightBreakdown{FreightType: freightType, Weight: NewQuantity(weight, RoundHalfUp)}
+	var freight, noTax Decimal
+	if freightType == FreightTypeUnitPrice {
+		if weight.IsZero() {
+			return nil, fmt.Errorf("单价订单的货物总重量不能为0")
+		}
+		b.UnitPrice = c.money(price)
+		b.UnitNoTaxPrice = c.money(noTaxPrice)
+		freight = c.round(price.Mul(weight))
+		noTax = c.round(noTaxPrice.Mul(weight))
+	} else {
+		freight = c.round(price)
+		noTax = c.round(noTaxPrice)
+	}
+	b.Freight = c.money(freight)
+	b.ConsignorNoTaxMoney = c.money(noTax)
+
+	var advance Decimal
+	if flag, _ := ParseYesNo(string(info.AdvanceFlag)); flag == Yes {
+		ratio, err := ParseDecimal(info.AdvanceRatio)
+		if err != nil {
+			return nil, fmt.Errorf("预付比例格式错误: %w", err)
+		}
+		advance = c.round(freight.Percent(ratio))
+	}
+	b.Advance = c.money(advance)
+	b.Balance = c.money(freight.Sub(advance))
+
+	oil, gas, err := c.oilGas(info, freight)
+	if err != nil {
+		return nil, err
+	}
+	b.Oil = c.money(oil)
+	b.Gas = c.money(gas)
+	b.Cash = c.money(freight.Sub(oil).Sub(gas))
+	return b, nil
+}
+
+// prices 返回运费和承运方预估到手价（每吨或整车），只填写一项时按服务费比例推算另一项
+func (c *FreightCalculator) prices(info *OrderInfo) (Decimal, Decimal, error) {
+	price, err := info.TotalAmount.Decimal()
+	if err != nil {
+		return Decimal{}, Decimal{}, fmt.Errorf("运费格式错误: %w", err)
+	}
+	noTax, err := info.ConsignorNoTaxMoney.Decimal()
+	if err != nil {
+		return Decimal{}, Decimal{}, fmt.Errorf("承运方预估到手价格式错误: %w", err)
+	}
+
+	rate, err := c.serviceRate()
+	if err != nil {
+		return Decimal{}, Decimal{}, err
+	}
+	keep := NewDecimal(100, 0).Sub(rate)
+
+	switch {
+	case info.TotalAmount != "" && info.ConsignorNoTaxMoney != "":
+		return price, noTax, nil
+	case info.TotalAmount != "":
+		return price, c.round(price.Percent(keep)), nil
+	case info.ConsignorNoTaxMoney != "":
+		// 运费 = 到手价 ÷ (100 - 比例)%，按金额精度舍入，与填入订单的价格一致
+		price, err := noTax.Mul(NewDecimal(100, 0)).Div(keep, MoneyScale, c.Rounding)
+		if err != nil {
+			return Decimal{}, Decimal{}, err
+		}
+		return price, noTax, nil
+	default:
+		return Decimal{}, Decimal{}, fmt.Errorf("运费与承运方预估到手价至少填写一项")
+	}
+}
+
+// oilGas 按订单的油气品分配计算油品和气品金额
+func (c *FreightCalculator) oilGas(info *OrderInfo, freight Decimal) (Decimal, Decimal, error) {
+	split, err := OilGasAllocationOf(info).Split(c.money(freight), c.Rounding)
+	if err != nil {
+		return Decimal{}, Decimal{}, err
+	}
+	oil, _ := split.Oil.Decimal()
+	gas, _ := split.Gas.Decimal()
+	return oil, gas, nil
+}
+
+// round 按计算器的舍入模式保留2位小数
+func (c *FreightCalculator) round(d Decimal) Decimal {
+	return d.Round(MoneyScale, c.Rounding)
+}
+
+// money 按计算器的舍入模式转换为金额
+func (c *FreightCalculator) money(d Decimal) Money {
+	return NewMoney(d, c.Rounding)
+}
@@ -0,0 +1,78 @@
+package zczy
+
+import "testing"
+
+func TestFreightCalculatorUnitPrice(t *testing.T) {
+	calc, err := NewFreightCalculator("6", RoundHalfUp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := newValidCreateOrderRequest()
+	req.OrderInfo.TotalAmount = "180.50"
+	req.OrderInfo.SupportSdOilCardFlag = Yes
+	req.OrderInfo.OilCardRatio = "10"
+	req.OrderInfo.GasPercent = "5"
+	req.CargoList = append(req.CargoList, CargoInfo{Weight: "2.25"})
+
+	b, err := calc.CalculateRequest(req)
+	if err != nil {
+		t.Fatalf("CalculateRequest() error = %v", err)
+	}
+
+	// 总重量32.25吨，运费 180.50×32.25=5821.125
+	want := FreightBreakdown{
+		FreightType:         FreightTypeUnitPrice,
+		Weight:              "32.2500",
+		UnitPrice:           "180.50",
+		UnitNoTaxPrice:      "169.67",
+		Freight:             "5821.13",
+		ConsignorNoTaxMoney: "5471.86",
+		Advance:             "1746.34",
+		Balance:             "4074.79",
+		Oil:                 "582.11",
+		Gas:                 "291.06",
+		Cash:                "4947.96",
+	}
+	if *b != want {
+		t.Errorf("CalculateRequest() =\n%+v\nwant\n%+v", *b, want)
+	}
+}
+
+func TestFreightCalculatorWholeTruck(t *testing.T) {
+	calc, _ := NewFreightCalculator("6", RoundHalfUp)
+
+	info := &OrderInfo{
+		FreightType:          FreightTypeWholeTruck,
+		ConsignorNoTaxMoney:  "4700",
+		AdvanceFlag:          No,
+		SupportSdOilCardFlag: Yes,
+		OilFixedCredit:       "500",
+	}
+	b, err := calc.Calculate(info, []CargoInfo{{Weight: "30"}})
+	if err != nil {
+		t.Fatalf("Calculate() error = %v", err)
+	}
+	// 运费 = 4700 ÷ 94% = 5000
+	if b.Freight != "5000.00" || b.Advance != "0.00" || b.Oil != "500.00" || b.Cash != "4500.00" {
+		t.Errorf("Calculate() = %+v", b)
+	}
+
+	b.Fill(info)
+	if info.TotalAmount != "5000.00" || info.ConsignorNoTaxMoney != "4700.00" {
+		t.Errorf("Fill() = %s / %s", info.TotalAmount, info.ConsignorNoTaxMoney)
+	}
+
+	if _, err := NewFreightCalculator("100", RoundHalfUp); err == nil {
+		t.Error("服务费比例为100应返回错误")
+	}
+	if _, err := calc.Calculate(&OrderInfo{FreightType: FreightTypeWholeTruck}, nil); err == nil {
+		t.Error("缺少运费应返回错误")
+	}
+
+	// 直接构造的计算器不经过 NewFreightCalculator 校验
+	bad := &FreightCalculator{ServiceRate: "abc"}
+	if _, err := bad.Calculate(&OrderInfo{FreightType: FreightTypeWholeTruck, TotalAmount: "5000"}, nil); err == nil {
+		t.Error("服务费比例格式错误应返回错误")
+	}
+}
@@ -0,0 +1,171 @@
+package zczy
+
+import "fmt"
+
+// OilGasMode 油气品设置方式
+type OilGasMode string
+
+const (
+	// OilGasNone 不包含油气品
+	OilGasNone OilGasMode = ""
+	// OilGasByRatio 按运费比例
+	OilGasByRatio OilGasMode = "ratio"
+	// OilGasByFixed 按固定额度
+	OilGasByFixed OilGasMode = "fixed"
+)
+
+// OilGasAllocation 油气品分配，比例与固定额度只能选择一种
+type OilGasAllocation struct {
+	OilRatio string // 油品比例（运费的百分比）
+	GasRatio string // 气品比例（运费的百分比）
+	OilFixed Money  // 油品固定额度
+	GasFixed Money  // 气品固定额度
+}
+
+// OilGasRatio 按运费比例分配油气品，例如 OilGasRatio("10", "5") 表示油品10%、气品5%
+func OilGasRatio(oil, gas string) OilGasAllocation {
+	return OilGasAllocation{OilRatio: oil, GasRatio: gas}
+}
+
+// OilGasFixed 按固定额度分配油气品
+func OilGasFixed(oil, gas Money) OilGasAllocation {
+	return OilGasAllocation{OilFixed: oil, GasFixed: gas}
+}
+
+// OilGasAllocationOf 读取订单信息中的油气品分配，不包含油气品时返回零值
+func OilGasAllocationOf(info *OrderInfo) OilGasAllocation {
+	if flag, _ := ParseYesNo(string(info.SupportSdOilCardFlag)); flag != Yes {
+		return OilGasAllocation{}
+	}
+	return OilGasAllocation{
+		OilRatio: info.OilCardRatio,
+		GasRatio: info.GasPercent,
+		OilFixed: info.OilFixedCredit,
+		GasFixed: info.GasFixedCredit,
+	}
+}
+
+// Mode 返回设置方式，同时填写比例和固定额度时按比例返回，由 Validate 报告冲突
+func (a OilGasAllocation) Mode() OilGasMode {
+	switch {
+	case a.OilRatio != "" || a.GasRatio != "":
+		return OilGasByRatio
+	case a.OilFixed != "" || a.GasFixed != "":
+		return OilGasByFixed
+	default:
+		return OilGasNone
+	}
+}
+
+// Validate 校验油气品分配；freight 为总运费，不为空时校验固定额度之和不超过运费
+// 返回的错误为 ValidationErrors，路径为 OrderInfo 中对应的JSON字段名
+func (a OilGasAllocation) Validate(freight Money) error {
+	v := &validator{}
+	a.validate(v, "")
+	a.validateFreight(v, "", freight)
+	return v.err()
+}
+
+// validate 校验比例、额度格式及组合，prefix 为字段路径前缀，例如 orderInfo
+func (a OilGasAllocation) validate(v *validator, prefix string) {
+	p := func(field string) string { return joinPath(prefix, field) }
+
+	if (a.OilRatio != "" || a.GasRatio != "") && (a.OilFixed != "" || a.GasFixed != "") {
+		v.addf(p("supportSdOilCardFlag"), "油气品比例与固定额度不能同时填写")
+		return
+	}
+
+	before := len(v.errs)
+	v.ratio(p("oilCardRatio"), a.OilRatio)
+	v.ratio(p("gasPercent"), a.GasRatio)
+	v.money(p("oilFixedCredit"), a.OilFixed)
+	v.money(p("gasFixedCredit"), a.GasFixed)
+	if len(v.errs) > before {
+		return
+	}
+
+	if a.Mode() == OilGasByRatio {
+		oil, _ := decimalOrZero(a.OilRatio)
+		gas, _ := decimalOrZero(a.GasRatio)
+		if sum := oil.Add(gas); sum.Cmp(NewDecimal(100, 0)) > 0 {
+			v.addf(p("oilCardRatio"), "油品与气品比例之和不能超过100，实际为%s", sum)
+		}
+	}
+}
+
+// validateFreight 校验固定额度之和不超过运费，运费为空或格式错误时不校验
+func (a OilGasAllocation) validateFreight(v *validator, prefix string, freight Money) {
+	if a.Mode() != OilGasByFixed || freight == "" {
+		return
+	}
+	total, err := freight.Decimal()
+	if err != nil {
+		return
+	}
+	oil, _ := a.OilFixed.Decimal()
+	gas, _ := a.GasFixed.Decimal()
+	if sum := oil.Add(gas); sum.Cmp(total) > 0 {
+		v.addf(joinPath(prefix, "oilFixedCredit"), "油品与气品固定额度之和%s超过运费%s", sum, freight)
+	}
+}
+
+// OilGasSplit 运费的现金、油品、气品拆分
+type OilGasSplit struct {
+	Oil  Money // 油品金额
+	Gas  Money // 气品金额
+	Cash Money // 现金金额
+}
+
+// Split 按分配方式拆分运费，比例金额按舍入模式保留2位小数
+func (a OilGasAllocation) Split(freight Money, rounding RoundingMode) (*OilGasSplit, error) {
+	if err := a.Validate(freight); err != nil {
+		return nil, err
+	}
+	total, err := freight.Decimal()
+	if err != nil {
+		return nil, fmt.Errorf("运费格式错误: %w", err)
+	}
+
+	var oil, gas Decimal
+	switch a.Mode() {
+	case OilGasByRatio:
+		oilRatio, _ := decimalOrZero(a.OilRatio)
+		gasRatio, _ := decimalOrZero(a.GasRatio)
+		oil = total.Percent(oilRatio).Round(MoneyScale, rounding)
+		gas = total.Percent(gasRatio).Round(MoneyScale, rounding)
+	case OilGasByFixed:
+		oil, _ = a.OilFixed.Decimal()
+		gas, _ = a.GasFixed.Decimal()
+	}
+
+	return &OilGasSplit{
+		Oil:  NewMoney(oil, rounding),
+		Gas:  NewMoney(gas, rounding),
+		Cash: NewMoney(total.Sub(oil).Sub(gas), rounding),
+	}, nil
+}
+
+// apply 将分配写入订单信息，并清空另一种方式的字段
+func (a OilGasAllocation) apply(info *OrderInfo) {
+	info.SupportSdOilCardFlag = YesNoOf(a.Mode() != OilGasNone)
+	info.OilCardRatio = a.OilRatio
+	info.GasPercent = a.GasRatio
+	info.OilFixedCredit = a.OilFixed
+	info.GasFixedCredit = a.GasFixed
+}
+
+// joinPath 拼接字段路径，prefix 为空时返回字段名
+func joinPath(prefix, field string) string {
+	if prefix == "" {
+		return field
+	}
+	return prefix + "." + field
+}
+
+// decimalOrZero 解析十进制数字，空字符串视为0
+func decimalOrZero(s string) (Decimal, error) {
+	if s == "" {
+		return Decimal{}, nil
+	}
+	return ParseDecimal(s)
+}
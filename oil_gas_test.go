@@ -0,0 +1,136 @@
+package zczy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOilGasAllocationValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		allocation OilGasAllocation
+		freight    Money
+		wantPath   string
+	}{
+		{name: "不包含油气品", allocation: OilGasAllocation{}},
+		{name: "按比例", allocation: OilGasRatio("60", "40"), freight: "1000.00"},
+		{name: "按固定额度", allocation: OilGasFixed("600", "400.00"), freight: "1000.00"},
+		{name: "固定额度不校验空运费", allocation: OilGasFixed("600", "")},
+		{
+			name:       "比例与固定额度同时填写",
+			allocation: OilGasAllocation{OilRatio: "10", GasFixed: "100"},
+			wantPath:   "supportSdOilCardFlag",
+		},
+		{name: "比例之和超过100", allocation: OilGasRatio("60", "40.01"), wantPath: "oilCardRatio"},
+		{name: "单项比例为0", allocation: OilGasRatio("0", "10"), freight: "1000.00"},
+		{name: "比例为负数", allocation: OilGasRatio("-1", "10"), wantPath: "oilCardRatio"},
+		{name: "额度为负数", allocation: OilGasFixed("", "-1"), wantPath: "gasFixedCredit"},
+		{
+			name:       "固定额度超过运费",
+			allocation: OilGasFixed("600", "400.01"),
+			freight:    "1000.00",
+			wantPath:   "oilFixedCredit",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.allocation.Validate(tt.freight)
+			if tt.wantPath == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+			}
+			if errs.Field(tt.wantPath) == nil {
+				t.Errorf("缺少 %s 的错误，实际: %v", tt.wantPath, err)
+			}
+		})
+	}
+}
+
+func TestOilGasAllocationSplit(t *testing.T) {
+	split, err := OilGasRatio("12.5", "7").Split("1000.05", RoundHalfUp)
+	if err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+	// 1000.05×12.5%=125.00625，1000.05×7%=70.0035
+	want := OilGasSplit{Oil: "125.01", Gas: "70.00", Cash: "805.04"}
+	if *split != want {
+		t.Errorf("Split() = %+v, want %+v", *split, want)
+	}
+
+	split, err = OilGasFixed("300", "").Split("1000", RoundHalfUp)
+	if err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+	if want := (OilGasSplit{Oil: "300.00", Gas: "0.00", Cash: "700.00"}); *split != want {
+		t.Errorf("Split() = %+v, want %+v", *split, want)
+	}
+
+	if _, err := OilGasFixed("800", "300").Split("1000", RoundHalfUp); err == nil {
+		t.Error("固定额度超过运费时应返回错误")
+	}
+}
+
+func TestOrderInfoBuilderSetOilGasAllocation(t *testing.T) {
+	b := NewOrderInfoBuilder().
+		SetOilCardFixed(true, "200", "").
+		SetOilGasAllocation(OilGasAllocation{})
+	info := b.Build()
+	if info.SupportSdOilCardFlag != No || info.OilFixedCredit != "" {
+		t.Errorf("关闭油气品后应清空设置，实际: %+v", info)
+	}
+
+	b.SetOilGasAllocation(OilGasRatio("10", "5"))
+	info = b.Build()
+	if info.SupportSdOilCardFlag != Yes || info.OilCardRatio != "10" || info.GasPercent != "5" {
+		t.Errorf("油气品设置错误，实际: %+v", info)
+	}
+	if got := OilGasAllocationOf(info); got != OilGasRatio("10", "5") {
+		t.Errorf("OilGasAllocationOf() = %+v", got)
+	}
+	if len(b.errs) != 0 {
+		t.Errorf("不应记录错误，实际: %v", b.errs)
+	}
+
+	// 替换之前的设置，包括冲突错误
+	b = NewOrderInfoBuilder().
+		SetOilCard(true, "10", "").
+		SetOilCardFixed(true, "200", "").
+		SetOilGasAllocation(OilGasFixed("300", ""))
+	if len(b.errs) != 0 || b.Build().OilCardRatio != "" || b.Build().OilFixedCredit != "300" {
+		t.Errorf("SetOilGasAllocation 应替换之前的设置，errs = %v, info = %+v", b.errs, b.Build())
+	}
+	b.SetOilCard(true, "10", "")
+	if len(b.errs) != 1 {
+		t.Errorf("替换为固定额度后再调用 SetOilCard 应记录冲突，errs = %v", b.errs)
+	}
+}
+
+func TestCreateOrderRequestValidateOilGas(t *testing.T) {
+	// 单价订单：运费 5000.00/吨 × 30吨 = 150000.00
+	req := newValidCreateOrderRequest()
+	req.OrderInfo.SupportSdOilCardFlag = Yes
+	req.OrderInfo.OilFixedCredit = "150000.00"
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	req.OrderInfo.GasFixedCredit = "0.01"
+	var errs ValidationErrors
+	if !errors.As(req.Validate(), &errs) || errs.Field("orderInfo.oilFixedCredit") == nil {
+		t.Errorf("固定额度超过运费时应返回 orderInfo.oilFixedCredit 错误，实际: %v", errs)
+	}
+
+	req = newValidCreateOrderRequest()
+	req.OrderInfo.SupportSdOilCardFlag = Yes
+	req.OrderInfo.OilCardRatio = "80"
+	req.OrderInfo.GasPercent = "30"
+	if !errors.As(req.Validate(), &errs) || errs.Field("orderInfo.oilCardRatio") == nil {
+		t.Errorf("比例之和超过100时应返回 orderInfo.oilCardRatio 错误，实际: %v", errs)
+	}
+}
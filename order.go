@@ -3,6 +3,8 @@ package zczy
 const (
 	// MethodOrderCreateMore 生成普通货(支持单货、多货)
 	MethodOrderCreateMore = "zczy.api.order.create.more"
+	// MethodOrderCreateBatch 生成批量货
+	MethodOrderCreateBatch = "zczy.api.order.create.batch"
 	// MethodOrderCancel 订单取消
 	MethodOrderCancel = "zczy.api.order.cancel"
 	// MethodReceiptConfirm 回单确认
@@ -15,53 +17,53 @@ const (
 
 // OrderInfo 订单信息
 type OrderInfo struct {
-	OrderModel              string `json:"orderModel"`                        // 订单类型：抢单,竞价
-	FreightType             string `json:"freightType"`                       // 费用类型：包车价，单价
-	SelfComment             string `json:"selfComment"`                       // 自定义编号/标签
-	ContactName             string `json:"contactName"`                       // 紧急联系人
-	ContactPhone            string `json:"contactPhone"`                      // 紧急联系电话
-	VehicleType             string `json:"vehicleType"`                       // 车型要求
-	VehicleLength           string `json:"vehicleLength"`                     // 车长要求，单位米
-	UrgentFlag              string `json:"urgentFlag"`                        // 是否加急：否，是
-	DespatchStart           string `json:"despatchStart"`                     // 装货开始时间
-	DespatchEnd             string `json:"despatchEnd"`                       // 装货结束时间
-	ReceiveDate             string `json:"receiveDate"`                       // 收货时间
-	ExpectTime              string `json:"expectTime,omitempty"`              // 报价结束时间（竞价时必填）
-	TotalAmount             string `json:"totalAmount,omitempty"`             // 运费（抢单时填）
-	ConsignorNoTaxMoney     string `json:"consignorNoTaxMoney,omitempty"`     // 承运方预估到手价（抢单时填）
-	CargoMoney              string `json:"cargoMoney"`                        // 货物价值
-	Prompt                  string `json:"prompt,omitempty"`                  // 装卸货要求
-	AdvanceFlag             string `json:"advanceFlag"`                       // 是否预付：否，是
-	AdvanceRatio            string `json:"advanceRatio,omitempty"`            // 预付比例
-	ReceiptFlag             string `json:"receiptFlag"`                       // 是否押回单：否,是
-	PolicyFlag              string `json:"policyFlag"`                        // 是否购买保险
-	SupportSdOilCardFlag    string `json:"supportSdOilCardFlag"`              // 是否包含油气品：否,是
-	OilCardRatio            string `json:"oilCardRatio,omitempty"`            // 油品比例
-	GasPercent              string `json:"gasPercent,omitempty"`              // 汽品比例
-	OilFixedCredit          string `json:"oilFixedCredit,omitempty"`          // 油品固定额度
-	GasFixedCredit          string `json:"gasFixedCredit,omitempty"`          // 气品固定额度
-	RuleID                  string `json:"ruleId,omitempty"`                  // 自动成交规则Id
-	RuleName                string `json:"ruleName,omitempty"`                // 自动成交规则名称
-	TonRuleID               string `json:"tonRuleId,omitempty"`               // 亏涨吨扣款规则id
-	SettleBasis             string `json:"settleBasis"`                       // 结算依据
-	PickOrderAdvisoryPhone  string `json:"pickOrderAdvisoryPhone,omitempty"`  // 摘单咨询电话
-	SettlementAdvisoryPhone string `json:"settlementAdvisoryPhone,omitempty"` // 结算咨询电话
-	InterceptPrice          string `json:"interceptPrice"`                    // 拦标价
-	OrderMarking            string `json:"orderMarking,omitempty"`            // 运单标识
+	OrderModel              OrderModel   `json:"orderModel"`                        // 订单类型：抢单,竞价
+	FreightType             FreightType  `json:"freightType"`                       // 费用类型：包车价，单价
+	SelfComment             string       `json:"selfComment"`                       // 自定义编号/标签
+	ContactName             string       `json:"contactName"`                       // 紧急联系人
+	ContactPhone            string       `json:"contactPhone"`                      // 紧急联系电话
+	VehicleType             string       `json:"vehicleType"`                       // 车型要求
+	VehicleLength           string       `json:"vehicleLength"`                     // 车长要求，单位米
+	UrgentFlag              YesNo        `json:"urgentFlag"`                        // 是否加急：否，是
+	DespatchStart           PlatformTime `json:"despatchStart"`                     // 装货开始时间
+	DespatchEnd             PlatformTime `json:"despatchEnd"`                       // 装货结束时间
+	ReceiveDate             PlatformTime `json:"receiveDate"`                       // 收货时间
+	ExpectTime              PlatformTime `json:"expectTime,omitempty"`              // 报价结束时间（竞价时必填）
+	TotalAmount             Money        `json:"totalAmount,omitempty"`             // 运费（抢单时填）
+	ConsignorNoTaxMoney     Money        `json:"consignorNoTaxMoney,omitempty"`     // 承运方预估到手价（抢单时填）
+	CargoMoney              Money        `json:"cargoMoney"`                        // 货物价值
+	Prompt                  string       `json:"prompt,omitempty"`                  // 装卸货要求
+	AdvanceFlag             YesNo        `json:"advanceFlag"`                       // 是否预付：否，是
+	AdvanceRatio            string       `json:"advanceRatio,omitempty"`            // 预付比例
+	ReceiptFlag             YesNo        `json:"receiptFlag"`                       // 是否押回单：否,是
+	PolicyFlag              string       `json:"policyFlag"`                        // 是否购买保险
+	SupportSdOilCardFlag    YesNo        `json:"supportSdOilCardFlag"`              // 是否包含油气品：否,是
+	OilCardRatio            string       `json:"oilCardRatio,omitempty"`            // 油品比例
+	GasPercent              string       `json:"gasPercent,omitempty"`              // 汽品比例
+	OilFixedCredit          Money        `json:"oilFixedCredit,omitempty"`          // 油品固定额度
+	GasFixedCredit          Money        `json:"gasFixedCredit,omitempty"`          // 气品固定额度
+	RuleID                  string       `json:"ruleId,omitempty"`                  // 自动成交规则Id
+	RuleName                string       `json:"ruleName,omitempty"`                // 自动成交规则名称
+	TonRuleID               string       `json:"tonRuleId,omitempty"`               // 亏涨吨扣款规则id
+	SettleBasis             string       `json:"settleBasis"`                       // 结算依据
+	PickOrderAdvisoryPhone  string       `json:"pickOrderAdvisoryPhone,omitempty"`  // 摘单咨询电话
+	SettlementAdvisoryPhone string       `json:"settlementAdvisoryPhone,omitempty"` // 结算咨询电话
+	InterceptPrice          Money        `json:"interceptPrice"`                    // 拦标价
+	OrderMarking            string       `json:"orderMarking,omitempty"`            // 运单标识
 }
 
 // CargoInfo 货物信息
 type CargoInfo struct {
-	CargoName         string `json:"cargoName"`                   // 货物名称
-	CargoVersion      string `json:"cargoVersion,omitempty"`      // 规格型号
-	CargoCategory     string `json:"cargoCategory"`               // 货物类别：重货，泡货
-	Weight            string `json:"weight"`                      // 货物重量或体积
-	CargoLength       string `json:"cargoLength,omitempty"`       // 规格-长
-	CargoWidth        string `json:"cargoWidth,omitempty"`        // 规格-宽
-	CargoHeight       string `json:"cargoHeight,omitempty"`       // 规格-高
-	Pack              string `json:"pack"`                        // 包装类型
-	WarehouseName     string `json:"warehouseName,omitempty"`     // 仓库名称
-	WarehouseLocation string `json:"warehouseLocation,omitempty"` // 仓库位置
+	CargoName         string        `json:"cargoName"`                   // 货物名称
+	CargoVersion      string        `json:"cargoVersion,omitempty"`      // 规格型号
+	CargoCategory     CargoCategory `json:"cargoCategory"`               // 货物类别：重货，泡货
+	Weight            Quantity      `json:"weight"`                      // 货物重量或体积
+	CargoLength       string        `json:"cargoLength,omitempty"`       // 规格-长
+	CargoWidth        string        `json:"cargoWidth,omitempty"`        // 规格-宽
+	CargoHeight       string        `json:"cargoHeight,omitempty"`       // 规格-高
+	Pack              string        `json:"pack"`                        // 包装类型
+	WarehouseName     string        `json:"warehouseName,omitempty"`     // 仓库名称
+	WarehouseLocation string        `json:"warehouseLocation,omitempty"` // 仓库位置
 }
 
 // OrderAddressInfo 收发货信息
@@ -87,7 +89,7 @@ type OrderAddressInfo struct {
 // OrderReceiptInfo 押回单信息
 type OrderReceiptInfo struct {
 	ReceiptLabel string `json:"receiptLabel,omitempty"` // 押回单标签
-	ReceiptMoney string `json:"receiptMoney,omitempty"` // 回单押金
+	ReceiptMoney Money  `json:"receiptMoney,omitempty"` // 回单押金
 }
 
 // CreateOrderRequest 创建订单请求
@@ -98,6 +100,13 @@ type CreateOrderRequest struct {
 	OrderReceiptInfo OrderReceiptInfo `json:"orderReceiptInfo,omitempty"` // 押回单信息（可选）
 }
 
+// clone 深拷贝请求，副本与原请求不共享切片
+func (r *CreateOrderRequest) clone() *CreateOrderRequest {
+	c := *r
+	c.CargoList = append([]CargoInfo(nil), r.CargoList...)
+	return &c
+}
+
 // CreateOrderResponse 创建订单响应
 type CreateOrderResponse struct {
 	OrderID string `json:"orderId"` // 订单号
@@ -110,19 +119,19 @@ type CancelOrderRequest struct {
 
 // ConfirmReceiptRequest 回单确认请求
 type ConfirmReceiptRequest struct {
-	OrderID             string `json:"orderId"`                       // 订单号
-	Tonnage             string `json:"tonnage"`                       // 收货吨位
-	SettleMoney         string `json:"settleMoney,omitempty"`         // 结算金额（与ConsignorNoTaxMoney二选一）
-	ConsignorNoTaxMoney string `json:"consignorNoTaxMoney,omitempty"` // 承运方预估到手价（与SettleMoney二选一）
-	SettleApplyFlag     string `json:"settleApplyFlag"`               // 是否提交结算申请：0-否，1-是
-	Remark              string `json:"remark,omitempty"`              // 备注
+	OrderID             string   `json:"orderId"`                       // 订单号
+	Tonnage             Quantity `json:"tonnage"`                       // 收货吨位
+	SettleMoney         Money    `json:"settleMoney,omitempty"`         // 结算金额（与ConsignorNoTaxMoney二选一）
+	ConsignorNoTaxMoney Money    `json:"consignorNoTaxMoney,omitempty"` // 承运方预估到手价（与SettleMoney二选一）
+	SettleApplyFlag     string   `json:"settleApplyFlag"`               // 是否提交结算申请：0-否，1-是
+	Remark              string   `json:"remark,omitempty"`              // 备注
 }
 
 // VehicleTrackRequest 获取车辆在途轨迹网址请求
 type VehicleTrackRequest struct {
-	OrderID          string `json:"orderId"`                    // 订单号
-	CreatedStartTime string `json:"createdStartTime,omitempty"` // 开始时间（格式：2021-08-02 12:20）
-	CreatedEndTime   string `json:"createdEndTime,omitempty"`   // 结束时间（格式：2021-08-02 13:20）
+	OrderID          string       `json:"orderId"`                    // 订单号
+	CreatedStartTime PlatformTime `json:"createdStartTime,omitempty"` // 开始时间（格式：2021-08-02 12:20）
+	CreatedEndTime   PlatformTime `json:"createdEndTime,omitempty"`   // 结束时间（格式：2021-08-02 13:20）
 }
 
 // VehicleTrackResponse 获取车辆在途轨迹网址响应
@@ -141,9 +150,9 @@ type Coordinate struct {
 
 // OrderCoordinateRequest 在途轨迹请求
 type OrderCoordinateRequest struct {
-	OrderID          string `json:"orderId"`                    // 订单号
-	CreatedStartTime string `json:"createdStartTime,omitempty"` // 开始时间（格式：2021-08-02 12:20）
-	CreatedEndTime   string `json:"createdEndTime,omitempty"`   // 结束时间（格式：2021-08-02 13:20）
+	OrderID          string       `json:"orderId"`                    // 订单号
+	CreatedStartTime PlatformTime `json:"createdStartTime,omitempty"` // 开始时间（格式：2021-08-02 12:20）
+	CreatedEndTime   PlatformTime `json:"createdEndTime,omitempty"`   // 结束时间（格式：2021-08-02 13:20）
 }
 
 // OrderCoordinateResponse 在途轨迹响应
@@ -170,7 +179,7 @@ func (c *Client) CreateOrder(req *CreateOrderRequest) (*CreateOrderResponse, err
 	return &result, nil
 }
 
-// CancelOrder 取消订单
+// CancelOrder 取消订单，平台返回失败时返回 *APIError
 func (c *Client) CancelOrder(orderID string) error {
 	req := &CancelOrderRequest{
 		OrderID: orderID,
@@ -181,25 +190,17 @@ func (c *Client) CancelOrder(orderID string) error {
 		return err
 	}
 
-	if !resp.IsSuccess() {
-		return nil
-	}
-
-	return nil
+	return resp.Err()
 }
 
-// ConfirmReceipt 回单确认
+// ConfirmReceipt 回单确认，平台返回失败时返回 *APIError
 func (c *Client) ConfirmReceipt(req *ConfirmReceiptRequest) error {
 	resp, err := c.Execute(MethodReceiptConfirm, req)
 	if err != nil {
 		return err
 	}
 
-	if !resp.IsSuccess() {
-		return nil
-	}
-
-	return nil
+	return resp.Err()
 }
 
 // GetVehicleTrack 获取车辆在途轨迹网址
@@ -227,6 +228,11 @@ func (c *Client) GetVehicleTrack(req *VehicleTrackRequest) (*VehicleTrackRespons
 
 // GetOrderCoordinate 获取订单在途轨迹坐标
 func (c *Client) GetOrderCoordinate(req *OrderCoordinateRequest) (*OrderCoordinateResponse, error) {
+	for _, t := range []PlatformTime{req.CreatedStartTime, req.CreatedEndTime} {
+		if err := t.Validate(); err != nil {
+			return nil, err
+		}
+	}
 	resp, err := c.Execute(MethodOrderCoordinate, req)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,181 @@
+package zczy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// BatchConfig 批量操作配置
+type BatchConfig struct {
+	Concurrency       int // 同时进行的请求数，默认4
+	RequestsPerSecond int // 每秒最多发起的请求数，0表示不限制，超过1e9时按每纳秒一次
+}
+
+// BatchStatus 批量操作中单个订单的结果状态
+type BatchStatus string
+
+const (
+	// BatchSucceeded 成功
+	BatchSucceeded BatchStatus = "succeeded"
+	// BatchAPIError 平台返回失败，Code为平台返回码
+	BatchAPIError BatchStatus = "api_error"
+	// BatchTransportError 网络等原因导致请求失败，平台可能未收到请求
+	BatchTransportError BatchStatus = "transport_error"
+	// BatchSkipped ctx取消后未发起请求，或发起前确认订单无需处理
+	BatchSkipped BatchStatus = "skipped"
+)
+
+// errBatchSkip 由批量操作的单个订单函数返回，表示未发起请求，结果为 BatchSkipped
+var errBatchSkip = errors.New("未发起请求")
+
+// BatchResult 单个订单的操作结果
+type BatchResult struct {
+	OrderID string      // 订单号
+	Status  BatchStatus // 结果状态
+	Code    string      // 平台返回码（仅BatchAPIError）
+	Err     error       // 失败原因
+}
+
+// BatchSummary 批量操作汇总
+type BatchSummary struct {
+	Total           int // 订单总数
+	Succeeded       int // 成功数
+	APIErrors       int // 平台返回失败数
+	TransportErrors int // 请求失败数
+	Skipped         int // 未发起请求数
+}
+
+// BatchReport 批量操作结果，Results 与传入的订单顺序一致
+type BatchReport struct {
+	Results []BatchResult
+	Summary BatchSummary
+}
+
+// Failed 返回未成功的结果（含未发起请求的订单），便于重试
+func (r *BatchReport) Failed() []BatchResult {
+	var failed []BatchResult
+	for _, result := range r.Results {
+		if result.Status != BatchSucceeded {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// CancelOrders 批量取消订单
+// ctx 取消后不再发起新的请求，未发起的订单结果为 BatchSkipped，并返回 ctx.Err()
+func (c *Client) CancelOrders(ctx context.Context, orderIDs []string, config *BatchConfig) (*BatchReport, error) {
+	return runBatch(ctx, orderIDs, config, func(i int) error {
+		return c.CancelOrder(orderIDs[i])
+	})
+}
+
+// ConfirmReceipts 批量回单确认
+// ctx 取消后不再发起新的请求，未发起的订单结果为 BatchSkipped，并返回 ctx.Err()
+func (c *Client) ConfirmReceipts(ctx context.Context, reqs []*ConfirmReceiptRequest, config *BatchConfig) (*BatchReport, error) {
+	orderIDs := make([]string, len(reqs))
+	for i, req := range reqs {
+		orderIDs[i] = req.OrderID
+	}
+	return runBatch(ctx, orderIDs, config, func(i int) error {
+		return c.ConfirmReceipt(reqs[i])
+	})
+}
+
+// runBatch 以有限并发和速率对每个订单执行一次fn
+func runBatch(ctx context.Context, orderIDs []string, config *BatchConfig, fn func(i int) error) (*BatchReport, error) {
+	n := len(orderIDs)
+	var cfg BatchConfig
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+
+	var tick <-chan time.Time
+	if cfg.RequestsPerSecond > 0 {
+		// 超过每纳秒一次时间隔为0，NewTicker 会panic，按最小间隔处理
+		interval := max(time.Second/time.Duration(cfg.RequestsPerSecond), time.Nanosecond)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	report := &BatchReport{Results: make([]BatchResult, n)}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.Concurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				report.Results[i] = batchResultOf(orderIDs[i], fn(i))
+			}
+		}()
+	}
+
+	next := 0
+dispatch:
+	for ; next < n; next++ {
+		if ctx.Err() != nil {
+			break
+		}
+		if tick != nil && next > 0 {
+			select {
+			case <-ctx.Done():
+				break dispatch
+			case <-tick:
+			}
+		}
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- next:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := next; i < n; i++ {
+		report.Results[i] = BatchResult{OrderID: orderIDs[i], Status: BatchSkipped, Err: ctx.Err()}
+	}
+
+	report.Summary.Total = n
+	for _, result := range report.Results {
+		switch result.Status {
+		case BatchSucceeded:
+			report.Summary.Succeeded++
+		case BatchAPIError:
+			report.Summary.APIErrors++
+		case BatchTransportError:
+			report.Summary.TransportErrors++
+		case BatchSkipped:
+			report.Summary.Skipped++
+		}
+	}
+
+	if next < n {
+		return report, ctx.Err()
+	}
+	return report, nil
+}
+
+// batchResultOf 根据错误类型生成单个订单的结果
+func batchResultOf(orderID string, err error) BatchResult {
+	result := BatchResult{OrderID: orderID, Status: BatchSucceeded, Err: err}
+	var apiErr *APIError
+	switch {
+	case err == nil:
+	case errors.Is(err, errBatchSkip):
+		result.Status = BatchSkipped
+	case errors.As(err, &apiErr):
+		result.Status = BatchAPIError
+		result.Code = apiErr.Code
+	default:
+		result.Status = BatchTransportError
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package zczy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCancelOrders(t *testing.T) {
+	var inflight, maxInflight int32
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		n := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInflight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInflight, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		var req CancelOrderRequest
+		json.Unmarshal([]byte(params), &req)
+		if req.OrderID == "ZC002" {
+			return &Response{Code: "1002", Message: "订单已摘单，不能取消"}
+		}
+		return &Response{Code: "0000"}
+	})
+
+	orderIDs := []string{"ZC001", "ZC002", "ZC003", "ZC004", "ZC005"}
+	report, err := client.CancelOrders(context.Background(), orderIDs, &BatchConfig{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("CancelOrders() error = %v", err)
+	}
+
+	if report.Summary != (BatchSummary{Total: 5, Succeeded: 4, APIErrors: 1}) {
+		t.Errorf("Summary = %+v", report.Summary)
+	}
+	if r := report.Results[1]; r.OrderID != "ZC002" || r.Status != BatchAPIError || r.Code != "1002" {
+		t.Errorf("Results[1] = %+v", r)
+	}
+	if failed := report.Failed(); len(failed) != 1 {
+		t.Errorf("Failed() = %+v", failed)
+	}
+	if maxInflight > 2 {
+		t.Errorf("最大并发 = %d, want <= 2", maxInflight)
+	}
+}
+
+func TestConfirmReceiptsTransportError(t *testing.T) {
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		return &Response{Code: "0000"}
+	})
+	client.SetGateway("http://127.0.0.1:1")
+
+	report, err := client.ConfirmReceipts(context.Background(), []*ConfirmReceiptRequest{
+		{OrderID: "ZC001", Tonnage: "30"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("ConfirmReceipts() error = %v", err)
+	}
+	if r := report.Results[0]; r.Status != BatchTransportError || r.Err == nil {
+		t.Errorf("Results[0] = %+v", r)
+	}
+}
+
+func TestBatchRateLimitAndCancel(t *testing.T) {
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		return &Response{Code: "0000"}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+
+	orderIDs := []string{"ZC001", "ZC002", "ZC003", "ZC004", "ZC005", "ZC006", "ZC007", "ZC008", "ZC009", "ZC010"}
+	report, err := client.CancelOrders(ctx, orderIDs, &BatchConfig{Concurrency: 4, RequestsPerSecond: 10})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CancelOrders() error = %v, want DeadlineExceeded", err)
+	}
+
+	// 每秒10次：250毫秒内最多发起3次
+	if report.Summary.Succeeded == 0 || report.Summary.Succeeded > 3 {
+		t.Errorf("Succeeded = %d", report.Summary.Succeeded)
+	}
+	if report.Summary.Succeeded+report.Summary.Skipped != len(orderIDs) {
+		t.Errorf("Summary = %+v", report.Summary)
+	}
+	if last := report.Results[len(orderIDs)-1]; last.Status != BatchSkipped || last.OrderID != "ZC010" {
+		t.Errorf("最后一个订单应被跳过: %+v", last)
+	}
+}
+
+func TestBatchVeryHighRequestsPerSecond(t *testing.T) {
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		return &Response{Code: "0000"}
+	})
+	// 间隔不足1纳秒时不应panic
+	report, err := client.CancelOrders(context.Background(), []string{"ZC001", "ZC002"}, &BatchConfig{RequestsPerSecond: 2_000_000_000})
+	if err != nil || report.Summary.Succeeded != 2 {
+		t.Errorf("CancelOrders() = %+v, %v", report.Summary, err)
+	}
+}
@@ -1,5 +1,26 @@
 package zczy
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// builderErrors 构建器在设置字段时记录的错误，路径为相对于所构建结构体的字段名
+type builderErrors []*FieldError
+
+// addf 记录一条字段错误
+func (e *builderErrors) addf(field, format string, args ...any) {
+	*e = append(*e, &FieldError{Path: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// addTo 将记录的错误加上路径前缀后添加到校验器
+func (e builderErrors) addTo(v *validator, prefix string) {
+	for _, fe := range e {
+		v.addf(prefix+"."+fe.Path, "%s", fe.Message)
+	}
+}
+
 // NewOrderInfoBuilder 创建订单信息构建器
 func NewOrderInfoBuilder() *OrderInfoBuilder {
 	return &OrderInfoBuilder{info: &OrderInfo{}}
@@ -8,14 +29,16 @@ func NewOrderInfoBuilder() *OrderInfoBuilder {
 // OrderInfoBuilder 订单信息构建器
 type OrderInfoBuilder struct {
 	info *OrderInfo
+	errs builderErrors
+	oil  string // 已启用的油气品设置方式：ratio-按比例，fixed-固定额度
 }
 
-func (b *OrderInfoBuilder) SetOrderModel(model string) *OrderInfoBuilder {
+func (b *OrderInfoBuilder) SetOrderModel(model OrderModel) *OrderInfoBuilder {
 	b.info.OrderModel = model
 	return b
 }
 
-func (b *OrderInfoBuilder) SetFreightType(freightType string) *OrderInfoBuilder {
+func (b *OrderInfoBuilder) SetFreightType(freightType FreightType) *OrderInfoBuilder {
 	b.info.FreightType = freightType
 	return b
 }
@@ -37,29 +60,46 @@ func (b *OrderInfoBuilder) SetVehicle(vehicleType, vehicleLength string) *OrderI
 	return b
 }
 
-func (b *OrderInfoBuilder) SetTimeSchedule(despatchStart, despatchEnd, receiveDate string) *OrderInfoBuilder {
+func (b *OrderInfoBuilder) SetTimeSchedule(despatchStart, despatchEnd, receiveDate PlatformTime) *OrderInfoBuilder {
 	b.info.DespatchStart = despatchStart
 	b.info.DespatchEnd = despatchEnd
 	b.info.ReceiveDate = receiveDate
 	return b
 }
 
-func (b *OrderInfoBuilder) SetExpectTime(expectTime string) *OrderInfoBuilder {
+// SetTimeScheduleAt 使用time.Time设置装货和收货时间，自动转换为中国标准时间
+func (b *OrderInfoBuilder) SetTimeScheduleAt(despatchStart, despatchEnd, receiveDate time.Time) *OrderInfoBuilder {
+	for _, t := range []struct {
+		field string
+		value time.Time
+	}{
+		{"despatchStart", despatchStart},
+		{"despatchEnd", despatchEnd},
+		{"receiveDate", receiveDate},
+	} {
+		if t.value.IsZero() {
+			b.errs.addf(t.field, "时间不能为零值")
+		}
+	}
+	return b.SetTimeSchedule(NewPlatformTime(despatchStart), NewPlatformTime(despatchEnd), NewPlatformTime(receiveDate))
+}
+
+func (b *OrderInfoBuilder) SetExpectTime(expectTime PlatformTime) *OrderInfoBuilder {
 	b.info.ExpectTime = expectTime
 	return b
 }
 
-func (b *OrderInfoBuilder) SetTotalAmount(amount string) *OrderInfoBuilder {
+func (b *OrderInfoBuilder) SetTotalAmount(amount Money) *OrderInfoBuilder {
 	b.info.TotalAmount = amount
 	return b
 }
 
-func (b *OrderInfoBuilder) SetConsignorNoTaxMoney(money string) *OrderInfoBuilder {
+func (b *OrderInfoBuilder) SetConsignorNoTaxMoney(money Money) *OrderInfoBuilder {
 	b.info.ConsignorNoTaxMoney = money
 	return b
 }
 
-func (b *OrderInfoBuilder) SetCargoMoney(money string) *OrderInfoBuilder {
+func (b *OrderInfoBuilder) SetCargoMoney(money Money) *OrderInfoBuilder {
 	b.info.CargoMoney = money
 	return b
 }
@@ -74,36 +114,28 @@ func (b *OrderInfoBuilder) SetSettleBasis(basis string) *OrderInfoBuilder {
 	return b
 }
 
-func (b *OrderInfoBuilder) SetInterceptPrice(price string) *OrderInfoBuilder {
+func (b *OrderInfoBuilder) SetInterceptPrice(price Money) *OrderInfoBuilder {
 	b.info.InterceptPrice = price
 	return b
 }
 
 func (b *OrderInfoBuilder) SetUrgent(urgent bool) *OrderInfoBuilder {
-	if urgent {
-		b.info.UrgentFlag = "是"
-	} else {
-		b.info.UrgentFlag = "否"
-	}
+	b.info.UrgentFlag = YesNoOf(urgent)
 	return b
 }
 
+// SetAdvance 设置预付，enabled 为false时清空预付比例
 func (b *OrderInfoBuilder) SetAdvance(enabled bool, ratio string) *OrderInfoBuilder {
+	b.info.AdvanceFlag = YesNoOf(enabled)
+	b.info.AdvanceRatio = ""
 	if enabled {
-		b.info.AdvanceFlag = "是"
 		b.info.AdvanceRatio = ratio
-	} else {
-		b.info.AdvanceFlag = "否"
 	}
 	return b
 }
 
 func (b *OrderInfoBuilder) SetReceipt(enabled bool) *OrderInfoBuilder {
-	if enabled {
-		b.info.ReceiptFlag = "是"
-	} else {
-		b.info.ReceiptFlag = "否"
-	}
+	b.info.ReceiptFlag = YesNoOf(enabled)
 	return b
 }
 
@@ -112,28 +144,56 @@ func (b *OrderInfoBuilder) SetPolicy(policyFlag string) *OrderInfoBuilder {
 	return b
 }
 
+// SetOilCard 按运费比例设置油气品，enabled 为false时不包含油气品
+// 与 SetOilCardFixed 同时启用时在 BuildValidated 时返回错误
 func (b *OrderInfoBuilder) SetOilCard(enabled bool, oilRatio, gasPercent string) *OrderInfoBuilder {
-	if enabled {
-		b.info.SupportSdOilCardFlag = "是"
-		b.info.OilCardRatio = oilRatio
-		b.info.GasPercent = gasPercent
-	} else {
-		b.info.SupportSdOilCardFlag = "否"
+	if !enabled {
+		return b.SetOilGasAllocation(OilGasAllocation{})
 	}
+	allocation := OilGasRatio(oilRatio, gasPercent)
+	b.useOil(string(allocation.Mode()))
+	allocation.apply(b.info)
 	return b
 }
 
-func (b *OrderInfoBuilder) SetOilCardFixed(enabled bool, oilFixed, gasFixed string) *OrderInfoBuilder {
-	if enabled {
-		b.info.SupportSdOilCardFlag = "是"
-		b.info.OilFixedCredit = oilFixed
-		b.info.GasFixedCredit = gasFixed
-	} else {
-		b.info.SupportSdOilCardFlag = "否"
+// SetOilCardFixed 按固定额度设置油气品，enabled 为false时不包含油气品
+// 与 SetOilCard 同时启用时在 BuildValidated 时返回错误
+func (b *OrderInfoBuilder) SetOilCardFixed(enabled bool, oilFixed, gasFixed Money) *OrderInfoBuilder {
+	if !enabled {
+		return b.SetOilGasAllocation(OilGasAllocation{})
 	}
+	allocation := OilGasFixed(oilFixed, gasFixed)
+	b.useOil(string(allocation.Mode()))
+	allocation.apply(b.info)
 	return b
 }
 
+// SetOilGasAllocation 设置油气品分配并替换之前的设置（包括 SetOilCard/SetOilCardFixed 的冲突错误），
+// 分配为零值时表示不包含油气品。比例与固定额度同时填写、比例之和超过100等问题在 BuildValidated 时返回
+func (b *OrderInfoBuilder) SetOilGasAllocation(allocation OilGasAllocation) *OrderInfoBuilder {
+	errs := b.errs[:0]
+	for _, fe := range b.errs {
+		if fe.Path != oilConflictField {
+			errs = append(errs, fe)
+		}
+	}
+	b.errs = errs
+	b.oil = string(allocation.Mode())
+	allocation.apply(b.info)
+	return b
+}
+
+// oilConflictField 油气品设置方式冲突错误的字段
+const oilConflictField = "supportSdOilCardFlag"
+
+// useOil 记录油气品设置方式，按比例和固定额度不能同时启用，mode 为空表示关闭
+func (b *OrderInfoBuilder) useOil(mode string) {
+	if b.oil != "" && mode != "" && b.oil != mode {
+		b.errs.addf(oilConflictField, "SetOilCard与SetOilCardFixed不能同时使用")
+	}
+	b.oil = mode
+}
+
 func (b *OrderInfoBuilder) SetAdvisoryPhones(pickPhone, settlementPhone string) *OrderInfoBuilder {
 	b.info.PickOrderAdvisoryPhone = pickPhone
 	b.info.SettlementAdvisoryPhone = settlementPhone
@@ -145,8 +205,24 @@ func (b *OrderInfoBuilder) SetOrderMarking(marking string) *OrderInfoBuilder {
 	return b
 }
 
+// Build 返回订单信息的副本，不做校验
 func (b *OrderInfoBuilder) Build() *OrderInfo {
-	return b.info
+	info := *b.info
+	return &info
+}
+
+// BuildValidated 返回订单信息的副本，并返回构建过程中记录的错误及字段校验错误
+// 错误为 ValidationErrors，路径以 orderInfo 开头
+func (b *OrderInfoBuilder) BuildValidated() (*OrderInfo, error) {
+	v := &validator{}
+	b.validate(v, "orderInfo")
+	return b.Build(), v.err()
+}
+
+// validate 校验构建器记录的错误及订单信息
+func (b *OrderInfoBuilder) validate(v *validator, prefix string) {
+	b.errs.addTo(v, prefix)
+	b.info.validate(v, prefix)
 }
 
 // NewCargoInfoBuilder 创建货物信息构建器
@@ -169,12 +245,13 @@ func (b *CargoInfoBuilder) SetCargoVersion(version string) *CargoInfoBuilder {
 	return b
 }
 
-func (b *CargoInfoBuilder) SetCargoCategory(category string) *CargoInfoBuilder {
+func (b *CargoInfoBuilder) SetCargoCategory(category CargoCategory) *CargoInfoBuilder {
 	b.info.CargoCategory = category
 	return b
 }
 
-func (b *CargoInfoBuilder) SetWeight(weight string) *CargoInfoBuilder {
+// SetWeight 设置货物重量，必须大于0，由 CargoInfo 的校验统一检查
+func (b *CargoInfoBuilder) SetWeight(weight Quantity) *CargoInfoBuilder {
 	b.info.Weight = weight
 	return b
 }
@@ -197,8 +274,18 @@ func (b *CargoInfoBuilder) SetWarehouse(name, location string) *CargoInfoBuilder
 	return b
 }
 
+// Build 返回货物信息的副本，不做校验
 func (b *CargoInfoBuilder) Build() *CargoInfo {
-	return b.info
+	info := *b.info
+	return &info
+}
+
+// BuildValidated 返回货物信息的副本，并返回字段校验错误
+// 错误为 ValidationErrors，按单条货物校验，路径与 CreateOrderRequest.Validate 一致，以 cargoList[0] 开头
+func (b *CargoInfoBuilder) BuildValidated() (*CargoInfo, error) {
+	v := &validator{}
+	b.info.validate(v, "cargoList[0]")
+	return b.Build(), v.err()
 }
 
 // NewOrderAddressInfoBuilder 创建收发货信息构建器
@@ -252,47 +339,110 @@ func (b *OrderAddressInfoBuilder) SetDeliverAddress(pro, city, dis, place string
 	return b
 }
 
+// Build 返回收发货信息的副本，不做校验
 func (b *OrderAddressInfoBuilder) Build() *OrderAddressInfo {
-	return b.info
+	info := *b.info
+	return &info
+}
+
+// BuildValidated 返回收发货信息的副本，并返回字段校验错误
+// 错误为 ValidationErrors，路径以 orderAddressInfo 开头
+func (b *OrderAddressInfoBuilder) BuildValidated() (*OrderAddressInfo, error) {
+	v := &validator{}
+	b.info.validate(v, "orderAddressInfo")
+	return b.Build(), v.err()
 }
 
 // NewCreateOrderRequestBuilder 创建订单请求构建器
 func NewCreateOrderRequestBuilder() *CreateOrderRequestBuilder {
 	return &CreateOrderRequestBuilder{
-		req: &CreateOrderRequest{CargoList: []CargoInfo{}},
+		orderInfo:   NewOrderInfoBuilder(),
+		addressInfo: NewOrderAddressInfoBuilder(),
 	}
 }
 
 // CreateOrderRequestBuilder 创建订单请求构建器
+// 既可以传入已构建的结构体，也可以直接传入子构建器，子构建器在Build时才生成结果
 type CreateOrderRequestBuilder struct {
-	req *CreateOrderRequest
+	orderInfo   *OrderInfoBuilder
+	cargoList   []*CargoInfoBuilder
+	addressInfo *OrderAddressInfoBuilder
+	receiptInfo OrderReceiptInfo
 }
 
 func (b *CreateOrderRequestBuilder) SetOrderInfo(orderInfo OrderInfo) *CreateOrderRequestBuilder {
-	b.req.OrderInfo = orderInfo
+	b.orderInfo = &OrderInfoBuilder{info: &orderInfo}
+	return b
+}
+
+// SetOrderInfoBuilder 使用订单信息构建器，构建器记录的错误会在BuildValidated时一并返回
+func (b *CreateOrderRequestBuilder) SetOrderInfoBuilder(builder *OrderInfoBuilder) *CreateOrderRequestBuilder {
+	b.orderInfo = builder
 	return b
 }
 
 func (b *CreateOrderRequestBuilder) AddCargo(cargo CargoInfo) *CreateOrderRequestBuilder {
-	b.req.CargoList = append(b.req.CargoList, cargo)
+	b.cargoList = append(b.cargoList, &CargoInfoBuilder{info: &cargo})
+	return b
+}
+
+// AddCargoBuilder 使用货物信息构建器添加一条货物
+func (b *CreateOrderRequestBuilder) AddCargoBuilder(builder *CargoInfoBuilder) *CreateOrderRequestBuilder {
+	b.cargoList = append(b.cargoList, builder)
 	return b
 }
 
 func (b *CreateOrderRequestBuilder) SetCargoList(cargoList []CargoInfo) *CreateOrderRequestBuilder {
-	b.req.CargoList = cargoList
+	b.cargoList = nil
+	for _, cargo := range cargoList {
+		b.AddCargo(cargo)
+	}
 	return b
 }
 
 func (b *CreateOrderRequestBuilder) SetOrderAddressInfo(addressInfo OrderAddressInfo) *CreateOrderRequestBuilder {
-	b.req.OrderAddressInfo = addressInfo
+	b.addressInfo = &OrderAddressInfoBuilder{info: &addressInfo}
+	return b
+}
+
+// SetOrderAddressInfoBuilder 使用收发货信息构建器
+func (b *CreateOrderRequestBuilder) SetOrderAddressInfoBuilder(builder *OrderAddressInfoBuilder) *CreateOrderRequestBuilder {
+	b.addressInfo = builder
 	return b
 }
 
 func (b *CreateOrderRequestBuilder) SetOrderReceiptInfo(receiptInfo OrderReceiptInfo) *CreateOrderRequestBuilder {
-	b.req.OrderReceiptInfo = receiptInfo
+	b.receiptInfo = receiptInfo
 	return b
 }
 
+// Build 生成新的创建订单请求，每次调用返回独立的副本，不做校验
 func (b *CreateOrderRequestBuilder) Build() *CreateOrderRequest {
-	return b.req
+	req := &CreateOrderRequest{
+		OrderInfo:        *b.orderInfo.Build(),
+		CargoList:        make([]CargoInfo, 0, len(b.cargoList)),
+		OrderAddressInfo: *b.addressInfo.Build(),
+		OrderReceiptInfo: b.receiptInfo,
+	}
+	for _, cargo := range b.cargoList {
+		req.CargoList = append(req.CargoList, *cargo.Build())
+	}
+	return req
+}
+
+// BuildValidated 生成新的创建订单请求，并返回各构建器记录的错误及 CreateOrderRequest.Validate 的校验结果
+// 错误为 ValidationErrors，路径与 Validate 一致，例如 cargoList[1].weight
+func (b *CreateOrderRequestBuilder) BuildValidated() (*CreateOrderRequest, error) {
+	req := b.Build()
+
+	v := &validator{}
+	b.orderInfo.errs.addTo(v, "orderInfo")
+	if err := req.Validate(); err != nil {
+		var errs ValidationErrors
+		if !errors.As(err, &errs) {
+			return req, err
+		}
+		v.errs = append(v.errs, errs...)
+	}
+	return req, v.err()
 }
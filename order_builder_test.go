@@ -0,0 +1,103 @@
+package zczy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBuilderBuildReturnsCopy(t *testing.T) {
+	builder := NewOrderInfoBuilder().SetSelfComment("A001")
+	first := builder.Build()
+	second := builder.SetSelfComment("A002").Build()
+
+	if first == second {
+		t.Fatal("多次Build不应返回同一个结构体")
+	}
+	if first.SelfComment != "A001" || second.SelfComment != "A002" {
+		t.Errorf("SelfComment = %s/%s, want A001/A002", first.SelfComment, second.SelfComment)
+	}
+
+	reqBuilder := NewCreateOrderRequestBuilder().AddCargo(CargoInfo{CargoName: "钢材"})
+	req1 := reqBuilder.Build()
+	req1.CargoList[0].CargoName = "铝材"
+	if req2 := reqBuilder.Build(); req2.CargoList[0].CargoName != "钢材" {
+		t.Errorf("修改Build结果不应影响构建器，实际 CargoName = %s", req2.CargoList[0].CargoName)
+	}
+}
+
+func TestOrderInfoBuilderOilCardConflict(t *testing.T) {
+	_, err := NewOrderInfoBuilder().
+		SetOilCard(true, "10", "").
+		SetOilCardFixed(true, "200", "").
+		BuildValidated()
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("BuildValidated() error = %v, want ValidationErrors", err)
+	}
+	if errs.Field("orderInfo.supportSdOilCardFlag") == nil {
+		t.Errorf("缺少油气品冲突错误，实际: %v", err)
+	}
+
+	// 关闭后重新设置不算冲突
+	b := NewOrderInfoBuilder().SetOilCard(false, "", "").SetOilCardFixed(true, "200", "")
+	if len(b.errs) != 0 {
+		t.Errorf("不应记录错误，实际: %v", b.errs)
+	}
+}
+
+func TestCreateOrderRequestBuilderWithSubBuilders(t *testing.T) {
+	valid := newValidCreateOrderRequest()
+
+	orderInfo := NewOrderInfoBuilder()
+	*orderInfo.info = valid.OrderInfo
+	orderInfo.SetOilCard(true, "10", "").SetOilCardFixed(true, "200", "")
+
+	req, err := NewCreateOrderRequestBuilder().
+		SetOrderInfoBuilder(orderInfo).
+		AddCargo(valid.CargoList[0]).
+		AddCargoBuilder(NewCargoInfoBuilder().
+			SetCargoName("铝材").
+			SetCargoCategory(CargoCategoryHeavy).
+			SetWeight("0").
+			SetPack("捆")).
+		SetOrderAddressInfoBuilder(NewOrderAddressInfoBuilder().
+			SetDespatchContact("发货公司", "李先生", "13800000001", "").
+			SetDespatchAddress("江苏省", "南京市", "鼓楼区", "燕江路201号").
+			SetDeliverCompany("收货公司").
+			SetDeliverContact("王先生", "13800000002", "").
+			SetDeliverAddress("上海市", "上海市", "浦东新区", "张江高科技园区100号")).
+		BuildValidated()
+
+	if req == nil || len(req.CargoList) != 2 || req.CargoList[1].CargoName != "铝材" {
+		t.Fatalf("BuildValidated() 结果错误: %+v", req)
+	}
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("BuildValidated() error = %v, want ValidationErrors", err)
+	}
+	for _, path := range []string{"orderInfo.supportSdOilCardFlag", "cargoList[1].weight"} {
+		if errs.Field(path) == nil {
+			t.Errorf("缺少字段 %s 的错误，实际: %v", path, err)
+		}
+	}
+	if len(errs) != 2 {
+		t.Errorf("错误数量 = %d, want 2: %v", len(errs), err)
+	}
+}
+
+func TestCargoInfoBuilderBuildValidated(t *testing.T) {
+	_, err := NewCargoInfoBuilder().SetCargoName("钢材").SetCargoCategory(CargoCategoryHeavy).BuildValidated()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || errs.Field("cargoList[0].weight") == nil {
+		t.Errorf("BuildValidated() error = %v, want cargoList[0].weight", err)
+	}
+}
+
+func TestOrderInfoBuilderSetAdvance(t *testing.T) {
+	info := NewOrderInfoBuilder().SetAdvance(true, "30").SetAdvance(false, "30").Build()
+	if info.AdvanceFlag != No || info.AdvanceRatio != "" {
+		t.Errorf("关闭预付后 AdvanceFlag = %s, AdvanceRatio = %q", info.AdvanceFlag, info.AdvanceRatio)
+	}
+}
@@ -0,0 +1,465 @@
+package zczy
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CSVImportStatus 导入结果状态
+type CSVImportStatus string
+
+const (
+	// CSVStatusCreated 创建成功
+	CSVStatusCreated CSVImportStatus = "created"
+	// CSVStatusInvalid 数据错误或校验不通过，未调用平台接口
+	CSVStatusInvalid CSVImportStatus = "invalid"
+	// CSVStatusFailed 调用平台接口失败
+	CSVStatusFailed CSVImportStatus = "failed"
+	// CSVStatusSkipped 结果文件中已创建成功，本次跳过
+	CSVStatusSkipped CSVImportStatus = "skipped"
+)
+
+// csvResultHeader 结果文件表头
+var csvResultHeader = []string{"key", "rows", "status", "orderId", "error"}
+
+// CSVImportConfig CSV导入配置
+type CSVImportConfig struct {
+	// Columns 表头到字段路径的映射，例如 "运费" -> "orderInfo.totalAmount"、"重量" -> "cargo.weight"
+	// 字段路径使用JSON字段名，订单字段以 orderInfo.、orderAddressInfo.、orderReceiptInfo. 开头，货物字段以 cargo. 开头
+	// 为空时表头本身即字段路径，未映射的列会被忽略
+	Columns map[string]string
+	// GroupBy 分组列的表头，取值相同的行合并为一个订单，每行一条货物；
+	// 为空时每行一个订单，必须映射 orderInfo.selfComment 列，自定义单号作为续传键，不能为空或重复
+	GroupBy string
+	// Base 默认值，CSV中的非空字段覆盖到其上；每条货物以 Base.CargoList[0] 为默认值
+	Base *CreateOrderRequest
+	// Concurrency 同时创建的订单数，默认4
+	Concurrency int
+	// ResultPath 结果文件路径（必填），每处理完一个订单追加一行
+	// 文件已存在时跳过其中已创建成功的订单，用于中断后继续导入
+	ResultPath string
+}
+
+// CSVImportResult 单个订单的导入结果
+type CSVImportResult struct {
+	Key     string          // 分组列的取值，未分组时为自定义单号
+	Rows    []int           // 对应的CSV行号（表头为第1行）
+	Status  CSVImportStatus // 结果状态
+	OrderID string          // 平台订单号
+	Err     error           // 失败原因
+}
+
+// CSVImportReport CSV导入汇总
+type CSVImportReport struct {
+	Results []CSVImportResult // 按订单在CSV中首次出现的顺序排列
+	Created int               // 创建成功数
+	Skipped int               // 跳过数（此前已创建成功）
+	Invalid int               // 校验不通过数
+	Failed  int               // 接口调用失败数
+}
+
+// csvGroup 一个订单对应的CSV行
+type csvGroup struct {
+	key  string
+	rows []int
+	data []map[string]string // 每行的 字段路径 -> 取值
+}
+
+// ImportOrdersCSV 从CSV批量创建订单，并将每个订单的结果追加写入 config.ResultPath
+// 创建过程中进程退出时，使用相同的结果文件再次导入即可跳过已成功的订单；
+// 正在提交、尚未写入结果的订单会被重新提交
+// ctx 取消后不再提交新的订单，已返回的报告只包含已处理的订单
+func (c *Client) ImportOrdersCSV(ctx context.Context, r io.Reader, config *CSVImportConfig) (*CSVImportReport, error) {
+	if config == nil || config.ResultPath == "" {
+		return nil, errors.New("resultPath is required")
+	}
+	concurrency := config.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	groups, err := readCSVGroups(r, config)
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := readCSVResults(config.ResultPath)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := openCSVResultWriter(config.ResultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CSVImportReport{Results: make([]CSVImportResult, len(groups))}
+	processed := make([]bool, len(groups))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, g := range groups {
+		if orderID, ok := done[g.key]; ok {
+			report.Results[i] = CSVImportResult{Key: g.key, Rows: g.rows, Status: CSVStatusSkipped, OrderID: orderID}
+			processed[i] = true
+			continue
+		}
+
+		req, err := g.request(config.Base)
+		if err != nil {
+			report.Results[i] = CSVImportResult{Key: g.key, Rows: g.rows, Status: CSVStatusInvalid, Err: err}
+			processed[i] = true
+			writer.write(&report.Results[i])
+			continue
+		}
+
+		acquired := false
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
+			acquired = true
+		}
+		if ctx.Err() != nil {
+			if acquired {
+				<-sem
+			}
+			break
+		}
+
+		processed[i] = true
+		wg.Add(1)
+		go func(i int, g *csvGroup, req *CreateOrderRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := CSVImportResult{Key: g.key, Rows: g.rows, Status: CSVStatusCreated}
+			if resp, err := c.CreateOrder(req); err != nil {
+				result.Status = CSVStatusFailed
+				result.Err = err
+			} else {
+				result.OrderID = resp.OrderID
+			}
+			report.Results[i] = result
+			writer.write(&report.Results[i])
+		}(i, g, req)
+	}
+	wg.Wait()
+
+	results := report.Results[:0]
+	for i, result := range report.Results {
+		if !processed[i] {
+			continue
+		}
+		switch result.Status {
+		case CSVStatusCreated:
+			report.Created++
+		case CSVStatusSkipped:
+			report.Skipped++
+		case CSVStatusInvalid:
+			report.Invalid++
+		case CSVStatusFailed:
+			report.Failed++
+		}
+		results = append(results, result)
+	}
+	report.Results = results
+
+	// 关闭失败时结果文件可能不完整，下次导入会重复提交，必须报告
+	if err := errors.Join(writer.err(), writer.close()); err != nil {
+		return report, fmt.Errorf("写入结果文件失败: %w", err)
+	}
+	return report, ctx.Err()
+}
+
+// readCSVGroups 读取CSV并按分组列合并
+func readCSVGroups(r io.Reader, config *CSVImportConfig) ([]*csvGroup, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("读取CSV表头失败: %v", err)
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff") // Excel导出的UTF-8 BOM
+
+	paths := make([]string, len(header))
+	groupCol, selfCommentCol := -1, -1
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == config.GroupBy && name != "" {
+			groupCol = i
+		}
+		path := name
+		if config.Columns != nil {
+			path = config.Columns[name]
+		}
+		if path == "" || (config.Columns == nil && !strings.Contains(path, ".")) {
+			continue
+		}
+		if !csvFieldPathValid(path) {
+			return nil, fmt.Errorf("列%q映射的字段路径无效: %s", name, path)
+		}
+		paths[i] = path
+		if path == "orderInfo.selfComment" {
+			selfCommentCol = i
+		}
+	}
+	if config.GroupBy != "" && groupCol < 0 {
+		return nil, fmt.Errorf("CSV中缺少分组列: %s", config.GroupBy)
+	}
+	if config.GroupBy == "" && selfCommentCol < 0 {
+		// 行号在文件被编辑后会变化，不能作为续传键
+		return nil, errors.New("未设置分组列时CSV中必须包含自定义单号列（orderInfo.selfComment）")
+	}
+	keyCol, keyName := groupCol, config.GroupBy
+	if groupCol < 0 {
+		keyCol, keyName = selfCommentCol, "自定义单号"
+	}
+
+	var groups []*csvGroup
+	index := make(map[string]*csvGroup)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取CSV第%d行失败: %v", line, err)
+		}
+		if csvRecordEmpty(record) {
+			continue
+		}
+
+		var key string
+		if keyCol < len(record) {
+			key = strings.TrimSpace(record[keyCol])
+		}
+		if key == "" {
+			return nil, fmt.Errorf("CSV第%d行%s为空", line, keyName)
+		}
+		if g, ok := index[key]; ok && groupCol < 0 {
+			return nil, fmt.Errorf("CSV第%d行自定义单号%s与第%d行重复", line, key, g.rows[0])
+		}
+
+		values := make(map[string]string)
+		for i, path := range paths {
+			if path != "" && i < len(record) {
+				values[path] = strings.TrimSpace(record[i])
+			}
+		}
+
+		g, ok := index[key]
+		if !ok {
+			g = &csvGroup{key: key}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.rows = append(g.rows, line)
+		g.data = append(g.data, values)
+	}
+	return groups, nil
+}
+
+// request 将一组CSV行转换为创建订单请求并校验
+func (g *csvGroup) request(base *CreateOrderRequest) (*CreateOrderRequest, error) {
+	sections := map[string]map[string]any{
+		"orderInfo":        {},
+		"orderAddressInfo": {},
+		"orderReceiptInfo": {},
+	}
+	sources := make(map[string]int) // 字段路径 -> 首次出现的行号
+	var cargoList []any
+
+	for i, values := range g.data {
+		cargo := make(map[string]any)
+		for path, value := range values {
+			if value == "" {
+				continue
+			}
+			section, field, _ := strings.Cut(path, ".")
+			if section == "cargo" {
+				cargo[field] = value
+				continue
+			}
+			if prev, ok := sections[section][field]; ok {
+				if prev != value {
+					return nil, fmt.Errorf("第%d行的%s与第%d行不一致", g.rows[i], path, sources[path])
+				}
+				continue
+			}
+			sections[section][field] = value
+			sources[path] = g.rows[i]
+		}
+		if len(cargo) > 0 {
+			cargoList = append(cargoList, cargo)
+		}
+	}
+
+	obj := map[string]any{"cargoList": cargoList}
+	for name, section := range sections {
+		obj[name] = section
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var parsed CreateOrderRequest
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("数据格式错误: %v", err)
+	}
+
+	req := &CreateOrderRequest{}
+	var defaultCargo CargoInfo
+	if base != nil {
+		*req = *base
+		req.CargoList = append([]CargoInfo(nil), base.CargoList...)
+		if len(base.CargoList) > 0 {
+			defaultCargo = base.CargoList[0]
+		}
+	}
+	mergeFields(&req.OrderInfo, &parsed.OrderInfo)
+	mergeFields(&req.OrderAddressInfo, &parsed.OrderAddressInfo)
+	mergeFields(&req.OrderReceiptInfo, &parsed.OrderReceiptInfo)
+	if len(parsed.CargoList) > 0 {
+		req.CargoList = make([]CargoInfo, len(parsed.CargoList))
+		for i := range parsed.CargoList {
+			req.CargoList[i] = defaultCargo
+			mergeFields(&req.CargoList[i], &parsed.CargoList[i])
+		}
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// csvFieldPathValid 判断字段路径是否对应请求结构体中的JSON字段
+func csvFieldPathValid(path string) bool {
+	section, field, ok := strings.Cut(path, ".")
+	if !ok {
+		return false
+	}
+	types := map[string]reflect.Type{
+		"orderInfo":        reflect.TypeOf(OrderInfo{}),
+		"orderAddressInfo": reflect.TypeOf(OrderAddressInfo{}),
+		"orderReceiptInfo": reflect.TypeOf(OrderReceiptInfo{}),
+		"cargo":            reflect.TypeOf(CargoInfo{}),
+	}
+	t, ok := types[section]
+	if !ok {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
+
+// csvRecordEmpty 判断是否为空行
+func csvRecordEmpty(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// readCSVResults 读取已有结果文件中创建成功的订单，返回 key -> 订单号
+func readCSVResults(path string) (map[string]string, error) {
+	done := make(map[string]string)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开结果文件失败: %v", err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// 进程中断时最后一行可能不完整，忽略之后的内容
+			break
+		}
+		if len(record) < 4 || record[0] == csvResultHeader[0] {
+			continue
+		}
+		if CSVImportStatus(record[2]) == CSVStatusCreated {
+			done[record[0]] = record[3]
+		}
+	}
+	return done, nil
+}
+
+// csvResultWriter 并发安全地追加写入结果文件
+type csvResultWriter struct {
+	mu       sync.Mutex
+	file     *os.File
+	writer   *csv.Writer
+	firstErr error
+}
+
+// openCSVResultWriter 以追加方式打开结果文件，新文件写入表头
+func openCSVResultWriter(path string) (*csvResultWriter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("打开结果文件失败: %v", err)
+	}
+	w := &csvResultWriter{file: f, writer: csv.NewWriter(f)}
+
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		w.writer.Write(csvResultHeader)
+		w.writer.Flush()
+	}
+	return w, nil
+}
+
+// write 写入一条结果并立即刷新到文件
+func (w *csvResultWriter) write(result *CSVImportResult) {
+	rows := make([]string, len(result.Rows))
+	for i, row := range result.Rows {
+		rows[i] = strconv.Itoa(row)
+	}
+	var msg string
+	if result.Err != nil {
+		msg = result.Err.Error()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writer.Write([]string{result.Key, strings.Join(rows, ";"), string(result.Status), result.OrderID, msg})
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil && w.firstErr == nil {
+		w.firstErr = err
+	}
+}
+
+// err 返回写入过程中的第一个错误
+func (w *csvResultWriter) err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.firstErr
+}
+
+func (w *csvResultWriter) close() error {
+	return w.file.Close()
+}
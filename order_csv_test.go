@@ -0,0 +1,166 @@
+package zczy
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testOrdersCSV = `单号,运费,货物,重量
+A,5000,钢材,20
+A,,铝材,10.5
+B,4000,钢材,abc
+C,3000,钢材,15
+`
+
+func TestImportOrdersCSV(t *testing.T) {
+	var mu sync.Mutex
+	failC := true
+	created := make(map[string]int)
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		var req CreateOrderRequest
+		json.Unmarshal([]byte(params), &req)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if req.OrderInfo.SelfComment == "C" && failC {
+			return &Response{Code: "9999", Message: "系统繁忙"}
+		}
+		created[req.OrderInfo.SelfComment]++
+		if req.OrderInfo.SelfComment == "A" && len(req.CargoList) != 2 {
+			t.Errorf("订单A应包含2条货物，实际 %d", len(req.CargoList))
+		}
+		return &Response{Code: "0000", Result: map[string]string{"orderId": "ZC-" + req.OrderInfo.SelfComment}}
+	})
+
+	config := &CSVImportConfig{
+		Columns: map[string]string{
+			"单号": "orderInfo.selfComment",
+			"运费": "orderInfo.totalAmount",
+			"货物": "cargo.cargoName",
+			"重量": "cargo.weight",
+		},
+		GroupBy:     "单号",
+		Base:        newValidCreateOrderRequest(),
+		Concurrency: 2,
+		ResultPath:  filepath.Join(t.TempDir(), "result.csv"),
+	}
+
+	report, err := client.ImportOrdersCSV(context.Background(), strings.NewReader(testOrdersCSV), config)
+	if err != nil {
+		t.Fatalf("ImportOrdersCSV() error = %v", err)
+	}
+	if report.Created != 1 || report.Invalid != 1 || report.Failed != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	if r := report.Results[0]; r.Key != "A" || r.OrderID != "ZC-A" || len(r.Rows) != 2 || r.Rows[1] != 3 {
+		t.Errorf("Results[0] = %+v", r)
+	}
+	if r := report.Results[1]; r.Status != CSVStatusInvalid || !strings.Contains(r.Err.Error(), "cargoList[0].weight") {
+		t.Errorf("Results[1] = %+v", r)
+	}
+
+	// 使用同一个结果文件再次导入：A跳过，C重试成功
+	mu.Lock()
+	failC = false
+	mu.Unlock()
+	report, err = client.ImportOrdersCSV(context.Background(), strings.NewReader(testOrdersCSV), config)
+	if err != nil {
+		t.Fatalf("ImportOrdersCSV() error = %v", err)
+	}
+	if report.Skipped != 1 || report.Created != 1 || report.Invalid != 1 {
+		t.Errorf("report = %+v", report)
+	}
+	if created["A"] != 1 || created["C"] != 1 {
+		t.Errorf("created = %v", created)
+	}
+
+	data, err := os.ReadFile(config.ResultPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	// 结果按处理完成的顺序写入
+	if len(lines) != 6 || lines[0] != "key,rows,status,orderId,error" || !strings.Contains(string(data), "\nA,2;3,created,ZC-A,\n") {
+		t.Errorf("结果文件内容:\n%s", data)
+	}
+}
+
+func TestImportOrdersCSVErrors(t *testing.T) {
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		return &Response{Code: "0000"}
+	})
+	resultPath := filepath.Join(t.TempDir(), "result.csv")
+
+	_, err := client.ImportOrdersCSV(context.Background(), strings.NewReader("orderInfo.totalAmont\n1\n"), &CSVImportConfig{ResultPath: resultPath})
+	if err == nil {
+		t.Error("无效的字段路径应返回错误")
+	}
+
+	_, err = client.ImportOrdersCSV(context.Background(), strings.NewReader("a,b\n1,2\n"), &CSVImportConfig{GroupBy: "单号", ResultPath: resultPath})
+	if err == nil {
+		t.Error("缺少分组列应返回错误")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err := client.ImportOrdersCSV(ctx, strings.NewReader(testOrdersCSV), &CSVImportConfig{
+		Columns:    map[string]string{"单号": "orderInfo.selfComment", "重量": "cargo.weight"},
+		GroupBy:    "单号",
+		Base:       newValidCreateOrderRequest(),
+		ResultPath: resultPath,
+	})
+	if err != context.Canceled {
+		t.Errorf("ImportOrdersCSV() error = %v, want context.Canceled", err)
+	}
+	if report.Created != 0 {
+		t.Errorf("ctx取消后不应提交订单: %+v", report)
+	}
+}
+
+func TestImportOrdersCSVUngroupedKey(t *testing.T) {
+	var mu sync.Mutex
+	created := make(map[string]int)
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		var req CreateOrderRequest
+		json.Unmarshal([]byte(params), &req)
+		mu.Lock()
+		defer mu.Unlock()
+		created[req.OrderInfo.SelfComment]++
+		return &Response{Code: "0000", Result: map[string]string{"orderId": "ZC-" + req.OrderInfo.SelfComment}}
+	})
+	config := &CSVImportConfig{
+		Columns:    map[string]string{"单号": "orderInfo.selfComment", "重量": "cargo.weight"},
+		Base:       newValidCreateOrderRequest(),
+		ResultPath: filepath.Join(t.TempDir(), "result.csv"),
+	}
+
+	for _, tt := range []struct{ name, csv string }{
+		{"缺少自定义单号列", "重量\n20\n"},
+		{"自定义单号为空", "单号,重量\nA,20\n,15\n"},
+		{"自定义单号重复", "单号,重量\nA,20\nA,15\n"},
+	} {
+		if _, err := client.ImportOrdersCSV(context.Background(), strings.NewReader(tt.csv), config); err == nil {
+			t.Errorf("%s: ImportOrdersCSV() error = nil", tt.name)
+		}
+	}
+
+	if _, err := client.ImportOrdersCSV(context.Background(), strings.NewReader("单号,重量\nA,20\nB,15\n"), config); err != nil {
+		t.Fatalf("ImportOrdersCSV() error = %v", err)
+	}
+	// 文件被编辑（插入新行）后续传，已创建的订单按自定义单号跳过
+	report, err := client.ImportOrdersCSV(context.Background(), strings.NewReader("单号,重量\nC,10\nA,20\nB,15\n"), config)
+	if err != nil {
+		t.Fatalf("ImportOrdersCSV() error = %v", err)
+	}
+	if report.Skipped != 2 || report.Created != 1 || report.Results[1].Key != "A" {
+		t.Errorf("report = %+v", report)
+	}
+	if created["A"] != 1 || created["B"] != 1 || created["C"] != 1 {
+		t.Errorf("created = %v", created)
+	}
+}
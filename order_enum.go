@@ -0,0 +1,246 @@
+package zczy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// OrderModel 订单类型
+// 下单接口使用中文（抢单、竞价），摘单回调使用数字（0-抢单，1-竞价），反序列化时两种写法都接受
+type OrderModel string
+
+const (
+	// OrderModelGrab 抢单
+	OrderModelGrab OrderModel = "抢单"
+	// OrderModelBidding 竞价
+	OrderModelBidding OrderModel = "竞价"
+)
+
+// FreightType 费用类型
+type FreightType string
+
+const (
+	// FreightTypeWholeTruck 包车价
+	FreightTypeWholeTruck FreightType = "包车价"
+	// FreightTypeUnitPrice 单价
+	FreightTypeUnitPrice FreightType = "单价"
+)
+
+// YesNo 是/否标志，反序列化时同时接受 1/0 和 true/false
+type YesNo string
+
+const (
+	// Yes 是
+	Yes YesNo = "是"
+	// No 否
+	No YesNo = "否"
+)
+
+// CargoCategory 货物类别
+type CargoCategory string
+
+const (
+	// CargoCategoryHeavy 重货
+	CargoCategoryHeavy CargoCategory = "重货"
+	// CargoCategoryLight 泡货
+	CargoCategoryLight CargoCategory = "泡货"
+)
+
+// enumSpec 枚举的取值及别名定义
+type enumSpec struct {
+	name    string
+	values  []string          // 平台要求的中文取值
+	aliases map[string]string // 别名 -> 中文取值
+}
+
+var (
+	orderModelSpec = enumSpec{
+		name:    "orderModel",
+		values:  []string{string(OrderModelGrab), string(OrderModelBidding)},
+		aliases: map[string]string{"0": string(OrderModelGrab), "1": string(OrderModelBidding)},
+	}
+	freightTypeSpec = enumSpec{
+		name:   "freightType",
+		values: []string{string(FreightTypeWholeTruck), string(FreightTypeUnitPrice)},
+	}
+	yesNoSpec = enumSpec{
+		name:   "yesNo",
+		values: []string{string(Yes), string(No)},
+		aliases: map[string]string{
+			"1": string(Yes), "0": string(No),
+			"true": string(Yes), "false": string(No),
+		},
+	}
+	cargoCategorySpec = enumSpec{
+		name:   "cargoCategory",
+		values: []string{string(CargoCategoryHeavy), string(CargoCategoryLight)},
+	}
+)
+
+// parse 将中文取值或别名转换为中文取值
+func (s *enumSpec) parse(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	for _, v := range s.values {
+		if value == v {
+			return v, nil
+		}
+	}
+	if v, ok := s.aliases[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+	return "", fmt.Errorf("未知的%s取值: %q", s.name, value)
+}
+
+// valid 判断是否为中文取值
+func (s *enumSpec) valid(value string) bool {
+	for _, v := range s.values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// marshal 序列化为中文取值，空值序列化为空字符串
+func (s *enumSpec) marshal(value string) ([]byte, error) {
+	if value == "" {
+		return []byte(`""`), nil
+	}
+	v, err := s.parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// unmarshal 反序列化字符串、数字或布尔值
+func (s *enumSpec) unmarshal(data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return "", nil
+	}
+
+	var raw string
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return "", err
+		}
+		if raw == "" {
+			return "", nil
+		}
+	} else {
+		raw = string(data)
+	}
+	return s.parse(raw)
+}
+
+// ParseOrderModel 解析订单类型，支持 抢单/竞价 及 0/1
+func ParseOrderModel(s string) (OrderModel, error) {
+	v, err := orderModelSpec.parse(s)
+	return OrderModel(v), err
+}
+
+// Valid 判断是否为合法的订单类型
+func (m OrderModel) Valid() bool {
+	return orderModelSpec.valid(string(m))
+}
+
+func (m OrderModel) MarshalJSON() ([]byte, error) {
+	return orderModelSpec.marshal(string(m))
+}
+
+func (m *OrderModel) UnmarshalJSON(data []byte) error {
+	v, err := orderModelSpec.unmarshal(data)
+	if err != nil {
+		return err
+	}
+	*m = OrderModel(v)
+	return nil
+}
+
+// ParseFreightType 解析费用类型
+func ParseFreightType(s string) (FreightType, error) {
+	v, err := freightTypeSpec.parse(s)
+	return FreightType(v), err
+}
+
+// Valid 判断是否为合法的费用类型
+func (t FreightType) Valid() bool {
+	return freightTypeSpec.valid(string(t))
+}
+
+func (t FreightType) MarshalJSON() ([]byte, error) {
+	return freightTypeSpec.marshal(string(t))
+}
+
+func (t *FreightType) UnmarshalJSON(data []byte) error {
+	v, err := freightTypeSpec.unmarshal(data)
+	if err != nil {
+		return err
+	}
+	*t = FreightType(v)
+	return nil
+}
+
+// ParseYesNo 解析是/否标志，支持 是/否、1/0、true/false
+func ParseYesNo(s string) (YesNo, error) {
+	v, err := yesNoSpec.parse(s)
+	return YesNo(v), err
+}
+
+// YesNoOf 将布尔值转换为是/否标志
+func YesNoOf(b bool) YesNo {
+	if b {
+		return Yes
+	}
+	return No
+}
+
+// Bool 判断标志是否为"是"
+func (f YesNo) Bool() bool {
+	return f == Yes
+}
+
+// Valid 判断是否为合法的是/否标志
+func (f YesNo) Valid() bool {
+	return yesNoSpec.valid(string(f))
+}
+
+func (f YesNo) MarshalJSON() ([]byte, error) {
+	return yesNoSpec.marshal(string(f))
+}
+
+func (f *YesNo) UnmarshalJSON(data []byte) error {
+	v, err := yesNoSpec.unmarshal(data)
+	if err != nil {
+		return err
+	}
+	*f = YesNo(v)
+	return nil
+}
+
+// ParseCargoCategory 解析货物类别
+func ParseCargoCategory(s string) (CargoCategory, error) {
+	v, err := cargoCategorySpec.parse(s)
+	return CargoCategory(v), err
+}
+
+// Valid 判断是否为合法的货物类别
+func (c CargoCategory) Valid() bool {
+	return cargoCategorySpec.valid(string(c))
+}
+
+func (c CargoCategory) MarshalJSON() ([]byte, error) {
+	return cargoCategorySpec.marshal(string(c))
+}
+
+func (c *CargoCategory) UnmarshalJSON(data []byte) error {
+	v, err := cargoCategorySpec.unmarshal(data)
+	if err != nil {
+		return err
+	}
+	*c = CargoCategory(v)
+	return nil
+}
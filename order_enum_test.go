@@ -0,0 +1,111 @@
+package zczy
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOrderModelJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  OrderModel
+	}{
+		{`"抢单"`, OrderModelGrab},
+		{`"竞价"`, OrderModelBidding},
+		{`"0"`, OrderModelGrab},
+		{`"1"`, OrderModelBidding},
+		{`0`, OrderModelGrab},
+		{`1`, OrderModelBidding},
+		{`""`, ""},
+		{`null`, ""},
+	}
+
+	for _, tt := range tests {
+		var got OrderModel
+		if err := json.Unmarshal([]byte(tt.input), &got); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Unmarshal(%s) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	var m OrderModel
+	if err := json.Unmarshal([]byte(`"拍卖"`), &m); err == nil {
+		t.Error("未知取值应返回错误")
+	}
+}
+
+func TestYesNoJSON(t *testing.T) {
+	for input, want := range map[string]YesNo{
+		`"是"`: Yes, `"否"`: No, `1`: Yes, `0`: No, `"1"`: Yes, `true`: Yes, `false`: No,
+	} {
+		var got YesNo
+		if err := json.Unmarshal([]byte(input), &got); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Unmarshal(%s) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestEnumMarshal(t *testing.T) {
+	info := OrderInfo{
+		OrderModel:    "0",
+		FreightType:   FreightTypeUnitPrice,
+		UrgentFlag:    "1",
+		AdvanceFlag:   No,
+		ReceiptFlag:   YesNoOf(true),
+		CargoMoney:    "100",
+		SettleBasis:   "按发货磅单结算",
+		DespatchStart: "2025-01-20 08:00",
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var result map[string]any
+	json.Unmarshal(data, &result)
+	for field, want := range map[string]string{
+		"orderModel":           "抢单",
+		"freightType":          "单价",
+		"urgentFlag":           "是",
+		"advanceFlag":          "否",
+		"receiptFlag":          "是",
+		"supportSdOilCardFlag": "",
+	} {
+		if result[field] != want {
+			t.Errorf("%s = %v, want %s", field, result[field], want)
+		}
+	}
+
+	info.CargoMoney = ""
+	info.FreightType = "按方"
+	if _, err := json.Marshal(info); err == nil {
+		t.Error("未知的费用类型序列化时应返回错误")
+	}
+}
+
+func TestDelistNotificationOrderModel(t *testing.T) {
+	var n DelistNotification
+	if err := json.Unmarshal([]byte(`{"orderModel":"1","orderId":"A001"}`), &n); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if n.OrderModel != OrderModelBidding {
+		t.Errorf("OrderModel = %q, want 竞价", n.OrderModel)
+	}
+}
+
+func TestParseCargoCategory(t *testing.T) {
+	if c, err := ParseCargoCategory("泡货"); err != nil || c != CargoCategoryLight {
+		t.Errorf("ParseCargoCategory(泡货) = %q, %v", c, err)
+	}
+	if _, err := ParseCargoCategory("轻货"); err == nil {
+		t.Error("未知货物类别应返回错误")
+	}
+}
@@ -0,0 +1,393 @@
+package zczy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EscalationConfig 自动加价配置
+//
+// 单价订单的运费（TotalAmount）为每吨价格，加价金额和上限同样按每吨计算；
+// 订单有拦标价（InterceptPrice）时，拦标价按相同金额同步提高。未填写运费的订单无法加价，记录一次失败的加价后停止等待
+type EscalationConfig struct {
+	Wait        time.Duration // 订单创建后等待摘单的时间，超过后取消并加价重新发布（必填）
+	Step        Money         // 每次加价金额，与 StepPercent 二选一
+	StepPercent string        // 每次按原始运费的百分比加价，例如 "5"
+	MaxAmount   Money         // 运费上限（必填），达到上限后不再加价，订单保持发布
+	MaxSteps    int           // 最多加价次数，0表示只受 MaxAmount 限制
+	Interval    time.Duration // Run 的检查间隔，默认1分钟
+	MaxRetries  int           // 取消或重新发布连续失败的最大次数，达到后停止等待，默认3
+
+	// Adjust 重新发布前对请求的其他调整（例如顺延装货时间），step 从1开始
+	Adjust func(req *CreateOrderRequest, step int)
+}
+
+// EscalationStep 一次加价记录
+type EscalationStep struct {
+	RootOrderID string    // 最初的订单号
+	Step        int       // 第几次加价，从1开始
+	OrderID     string    // 被取消的订单号
+	NewOrderID  string    // 重新发布的订单号，失败时为空
+	FromAmount  Money     // 加价前运费
+	ToAmount    Money     // 加价后运费
+	At          time.Time // 执行时间
+	Err         error     // 失败原因
+}
+
+// escalationWatch 等待摘单的订单
+type escalationWatch struct {
+	rootOrderID string
+	orderID     string
+	step        int       // 已加价次数
+	deadline    time.Time // 截止时间
+	cancelled   bool      // 已由加价流程取消，等待重新发布
+	failures    int       // 连续失败次数
+}
+
+// PriceEscalator 未按时摘单的订单自动加价
+//
+// 通过 CreateOrder 或 Watch 登记订单，截止时间前订单仍为已创建状态（未收到摘单通知）时，
+// 取消订单并按 Step/StepPercent 提高运费后重新发布，新订单继续等待，直到被摘单、达到上限或次数用完。
+// 订单状态来自 OrderTracker，需要将回调交给 tracker.HandleCallback。登记信息保存在内存中，重启后需重新 Watch
+type PriceEscalator struct {
+	tracker *OrderTracker
+	config  EscalationConfig
+	now     func() time.Time
+
+	mu      sync.Mutex
+	watches map[string]*escalationWatch // 按当前订单号
+	steps   []EscalationStep
+	checkMu sync.Mutex // 保证同一时间只有一次 Check
+}
+
+// NewPriceEscalator 创建自动加价调度器
+func NewPriceEscalator(tracker *OrderTracker, config *EscalationConfig) (*PriceEscalator, error) {
+	if tracker == nil {
+		return nil, errors.New("tracker is required")
+	}
+	if config == nil {
+		return nil, errors.New("config is required")
+	}
+	cfg := *config
+	if cfg.Wait <= 0 {
+		return nil, errors.New("等待摘单时间必须大于0")
+	}
+	if (cfg.Step == "") == (cfg.StepPercent == "") {
+		return nil, errors.New("加价金额与加价比例必须且只能填写一项")
+	}
+
+	v := &validator{}
+	v.money("step", cfg.Step)
+	v.percent("stepPercent", cfg.StepPercent)
+	if v.required("maxAmount", string(cfg.MaxAmount)) {
+		v.money("maxAmount", cfg.MaxAmount)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	step, _ := cfg.Step.Decimal()
+	percent, _ := ParseDecimal(cfg.StepPercent)
+	if step.Sign() <= 0 && percent.Sign() <= 0 {
+		return nil, errors.New("加价金额或加价比例必须大于0")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 3
+	}
+
+	return &PriceEscalator{
+		tracker: tracker,
+		config:  cfg,
+		now:     time.Now,
+		watches: make(map[string]*escalationWatch),
+	}, nil
+}
+
+// CreateOrder 通过跟踪器创建订单并开始等待摘单
+func (e *PriceEscalator) CreateOrder(req *CreateOrderRequest) (*CreateOrderResponse, error) {
+	resp, err := e.tracker.CreateOrder(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp, e.Watch(resp.OrderID)
+}
+
+// Watch 开始等待已通过跟踪器创建的订单被摘单，截止时间为记录创建时间 + Wait
+func (e *PriceEscalator) Watch(orderID string) error {
+	record, err := e.tracker.Get(orderID)
+	if err != nil {
+		return fmt.Errorf("读取订单记录失败: %w", err)
+	}
+	if record == nil || record.Request == nil {
+		return fmt.Errorf("%w: %s", ErrOrderNotTracked, orderID)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.watches[orderID] = &escalationWatch{
+		rootOrderID: orderID,
+		orderID:     orderID,
+		deadline:    record.CreatedAt.Add(e.config.Wait),
+	}
+	return nil
+}
+
+// Unwatch 停止等待，不影响平台上的订单
+func (e *PriceEscalator) Unwatch(orderID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.watches, orderID)
+}
+
+// Watching 返回正在等待摘单的订单号
+func (e *PriceEscalator) Watching() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ids := make([]string, 0, len(e.watches))
+	for id := range e.watches {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Steps 返回指定最初订单号的加价记录，rootOrderID 为空时返回全部记录
+func (e *PriceEscalator) Steps(rootOrderID string) []EscalationStep {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var steps []EscalationStep
+	for _, s := range e.steps {
+		if rootOrderID == "" || s.RootOrderID == rootOrderID {
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+// Run 按 Interval 定期执行 Check，直到 ctx 取消
+func (e *PriceEscalator) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+	for {
+		e.Check()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check 处理已到截止时间的订单，返回本次产生的加价记录
+// 已摘单或已被其他方式取消的订单停止等待；新请求在取消前生成并校验，无法重新发布的订单记录失败原因后停止等待，不会被取消；
+// 请求失败的订单保留，下次 Check 时重试，连续失败 MaxRetries 次后停止等待；
+// 订单正在被 Repost 或 StaleOrderSweeper 处理时跳过，下次 Check 时再处理
+func (e *PriceEscalator) Check() []EscalationStep {
+	e.checkMu.Lock()
+	defer e.checkMu.Unlock()
+
+	now := e.now()
+	e.mu.Lock()
+	var due []escalationWatch
+	for _, w := range e.watches {
+		if !now.Before(w.deadline) {
+			due = append(due, *w)
+		}
+	}
+	e.mu.Unlock()
+	sort.Slice(due, func(i, j int) bool { return due[i].deadline.Before(due[j].deadline) })
+
+	var steps []EscalationStep
+	for _, w := range due {
+		if step, ok := e.escalate(w, now); ok {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// escalate 处理单个到期订单，产生加价记录时返回true
+func (e *PriceEscalator) escalate(w escalationWatch, now time.Time) (EscalationStep, bool) {
+	// 与重新发布和过期清理互斥，避免同一订单被重复取消或重新发布
+	if !e.tracker.acquireRepost(w.orderID) {
+		return EscalationStep{}, false
+	}
+	defer e.tracker.releaseRepost(w.orderID)
+
+	record, err := e.tracker.Get(w.orderID)
+	if err != nil || record == nil || record.Request == nil {
+		return EscalationStep{}, false
+	}
+	if record.RepostedAs != "" {
+		// 已通过 Repost 或 ResolveRepost 重新发布为其他订单
+		e.Unwatch(w.orderID)
+		return EscalationStep{}, false
+	}
+	switch {
+	case record.Status == OrderStatusCreated:
+	case record.Status == OrderStatusCancelled && w.cancelled:
+	default:
+		// 已摘单、已被其他方式取消或已终止
+		e.Unwatch(w.orderID)
+		return EscalationStep{}, false
+	}
+
+	if e.config.MaxSteps > 0 && w.step >= e.config.MaxSteps {
+		// 加价次数用完，订单保持发布
+		e.Unwatch(w.orderID)
+		return EscalationStep{}, false
+	}
+
+	from := record.Request.OrderInfo.TotalAmount
+	step := EscalationStep{
+		RootOrderID: w.rootOrderID,
+		Step:        w.step + 1,
+		OrderID:     w.orderID,
+		FromAmount:  from,
+		At:          now,
+	}
+	to, err := e.nextAmount(w.rootOrderID, from)
+	if err != nil {
+		// 运费无法加价（例如议价订单未填写运费），停止等待
+		step.Err = fmt.Errorf("无法计算加价后运费: %w", err)
+		e.Unwatch(w.orderID)
+		return e.record(step), true
+	}
+	if to == "" {
+		// 已达到上限，订单保持发布
+		e.Unwatch(w.orderID)
+		return EscalationStep{}, false
+	}
+	step.ToAmount = to
+
+	// 取消前先生成并校验新请求，避免取消后无法重新发布
+	adj, err := e.adjustment(record.Request, step.Step, from, to)
+	var next *CreateOrderRequest
+	if err == nil {
+		next, err = PrepareRepost(record.Request, adj)
+	}
+	if err != nil {
+		step.Err = fmt.Errorf("重新发布请求无效: %w", err)
+		e.Unwatch(w.orderID)
+		return e.record(step), true
+	}
+
+	if !w.cancelled {
+		if err := e.tracker.CancelOrder(w.orderID); err != nil {
+			step.Err = fmt.Errorf("取消订单失败: %w", err)
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				// 平台拒绝取消，通常是订单刚被摘单
+				e.Unwatch(w.orderID)
+				return e.record(step), true
+			}
+			return e.retry(w, step), true
+		}
+		e.mu.Lock()
+		if cur, ok := e.watches[w.orderID]; ok {
+			cur.cancelled = true
+		}
+		e.mu.Unlock()
+	}
+
+	result, err := e.tracker.repostLocked(w.orderID, func(*CreateOrderRequest) (*CreateOrderRequest, error) {
+		return next, nil
+	})
+	if err != nil {
+		step.Err = fmt.Errorf("重新发布失败: %w", err)
+		if errors.Is(err, ErrOrderNotRepostable) || errors.Is(err, ErrOrderNotTracked) {
+			// 已被重新发布或记录缺失，重试也不会成功
+			e.Unwatch(w.orderID)
+			return e.record(step), true
+		}
+		return e.retry(w, step), true
+	}
+	step.NewOrderID = result.OrderID
+
+	e.mu.Lock()
+	delete(e.watches, w.orderID)
+	e.watches[result.OrderID] = &escalationWatch{
+		rootOrderID: w.rootOrderID,
+		orderID:     result.OrderID,
+		step:        step.Step,
+		deadline:    now.Add(e.config.Wait),
+	}
+	e.mu.Unlock()
+	return e.record(step), true
+}
+
+// adjustment 返回第 n 次加价的重新发布调整
+// 订单有拦标价时按相同金额同步提高，避免新运费高于拦标价
+func (e *PriceEscalator) adjustment(req *CreateOrderRequest, n int, from, to Money) (*RepostAdjustment, error) {
+	adj := &RepostAdjustment{TotalAmount: to}
+	if intercept := req.OrderInfo.InterceptPrice; intercept != "" {
+		price, err := intercept.Decimal()
+		if err != nil {
+			return nil, fmt.Errorf("拦标价格式错误: %w", err)
+		}
+		fromValue, _ := from.Decimal()
+		toValue, _ := to.Decimal()
+		adj.InterceptPrice = NewMoney(price.Add(toValue.Sub(fromValue)), RoundHalfUp)
+	}
+	if e.config.Adjust != nil {
+		adj.Modify = func(req *CreateOrderRequest) { e.config.Adjust(req, n) }
+	}
+	return adj, nil
+}
+
+// nextAmount 返回加价后的运费，不超过上限；已达到上限时返回空
+func (e *PriceEscalator) nextAmount(rootOrderID string, current Money) (Money, error) {
+	if current == "" {
+		return "", errors.New("运费为空")
+	}
+	amount, err := current.Decimal()
+	if err != nil {
+		return "", err
+	}
+	limit, _ := e.config.MaxAmount.Decimal()
+	if amount.Cmp(limit) >= 0 {
+		return "", nil
+	}
+
+	var step Decimal
+	if e.config.Step != "" {
+		step, _ = e.config.Step.Decimal()
+	} else {
+		original := amount
+		if root, err := e.tracker.Get(rootOrderID); err == nil && root != nil && root.Request != nil {
+			if d, err := root.Request.OrderInfo.TotalAmount.Decimal(); err == nil {
+				original = d
+			}
+		}
+		percent, _ := ParseDecimal(e.config.StepPercent)
+		step = original.Percent(percent)
+	}
+	return NewMoney(amount.Add(step).Min(limit), RoundHalfUp), nil
+}
+
+// retry 保存失败的加价记录并保留等待，连续失败达到 MaxRetries 后停止等待
+func (e *PriceEscalator) retry(w escalationWatch, step EscalationStep) EscalationStep {
+	e.mu.Lock()
+	if cur, ok := e.watches[w.orderID]; ok {
+		cur.failures++
+		if cur.failures >= e.config.MaxRetries {
+			delete(e.watches, w.orderID)
+		}
+	}
+	e.mu.Unlock()
+	return e.record(step)
+}
+
+// record 保存加价记录
+func (e *PriceEscalator) record(step EscalationStep) EscalationStep {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.steps = append(e.steps, step)
+	return step
+}
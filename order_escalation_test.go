@@ -0,0 +1,368 @@
+package zczy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+)
+
+// newEscalationTestRequest 返回装货时间未过期的订单请求
+func newEscalationTestRequest() *CreateOrderRequest {
+	req := newValidCreateOrderRequest()
+	req.OrderInfo.DespatchStart = NewPlatformTime(time.Now().Add(time.Hour))
+	req.OrderInfo.DespatchEnd = NewPlatformTime(time.Now().Add(6 * time.Hour))
+	req.OrderInfo.ReceiveDate = NewPlatformTime(time.Now().Add(48 * time.Hour))
+	return req
+}
+
+func TestNewPriceEscalator(t *testing.T) {
+	tracker, _ := newRepostTestTracker(t)
+	tests := []struct {
+		name   string
+		config EscalationConfig
+	}{
+		{"缺少等待时间", EscalationConfig{Step: "100", MaxAmount: "6000"}},
+		{"未填写加价方式", EscalationConfig{Wait: time.Hour, MaxAmount: "6000"}},
+		{"同时填写两种加价方式", EscalationConfig{Wait: time.Hour, Step: "100", StepPercent: "5", MaxAmount: "6000"}},
+		{"加价金额为0", EscalationConfig{Wait: time.Hour, Step: "0", MaxAmount: "6000"}},
+		{"加价比例格式错误", EscalationConfig{Wait: time.Hour, StepPercent: "abc", MaxAmount: "6000"}},
+		{"缺少上限", EscalationConfig{Wait: time.Hour, Step: "100"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPriceEscalator(tracker, &tt.config); err == nil {
+				t.Error("NewPriceEscalator() error = nil")
+			}
+		})
+	}
+
+	e, err := NewPriceEscalator(tracker, &EscalationConfig{Wait: time.Hour, StepPercent: "5", MaxAmount: "6000"})
+	if err != nil {
+		t.Fatalf("NewPriceEscalator() error = %v", err)
+	}
+	if e.config.Interval != time.Minute {
+		t.Errorf("Interval = %v, want 1m", e.config.Interval)
+	}
+}
+
+func TestPriceEscalator(t *testing.T) {
+	tracker, calls := newRepostTestTracker(t)
+	var adjusted []int
+	e, err := NewPriceEscalator(tracker, &EscalationConfig{
+		Wait:      2 * time.Hour,
+		Step:      "300.00",
+		MaxAmount: "5500.00",
+		Adjust:    func(req *CreateOrderRequest, step int) { adjusted = append(adjusted, step) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Now()
+	e.now = func() time.Time { return clock }
+
+	if _, err := e.CreateOrder(newEscalationTestRequest()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未到截止时间
+	if steps := e.Check(); len(steps) != 0 {
+		t.Fatalf("Check() = %v, want 无加价", steps)
+	}
+
+	clock = clock.Add(2*time.Hour + time.Second)
+	steps := e.Check()
+	if len(steps) != 1 {
+		t.Fatalf("Check() = %v, want 1 次加价", steps)
+	}
+	if s := steps[0]; s.Step != 1 || s.OrderID != "ZC001" || s.NewOrderID != "ZC002" || s.FromAmount != "5000.00" || s.ToAmount != "5300.00" || s.Err != nil {
+		t.Errorf("第1次加价 = %+v", s)
+	}
+	if got := e.Watching(); fmt.Sprint(got) != "[ZC002]" {
+		t.Errorf("Watching() = %v, want [ZC002]", got)
+	}
+
+	// 第2次加价受上限约束
+	clock = clock.Add(2 * time.Hour)
+	steps = e.Check()
+	if len(steps) != 1 || steps[0].ToAmount != "5500.00" || steps[0].NewOrderID != "ZC003" {
+		t.Fatalf("第2次加价 = %+v", steps)
+	}
+	created, _ := tracker.Get("ZC003")
+	if created.Request.OrderInfo.TotalAmount != "5500.00" || created.Request.OrderInfo.SelfComment != "TEST001-R2" || created.RepostOf != "ZC002" {
+		t.Errorf("ZC003 记录 = %+v", created)
+	}
+
+	// 已达到上限，订单保持发布
+	clock = clock.Add(2 * time.Hour)
+	if steps := e.Check(); len(steps) != 0 {
+		t.Errorf("达到上限后 Check() = %v", steps)
+	}
+	if got := e.Watching(); len(got) != 0 {
+		t.Errorf("Watching() = %v, want 空", got)
+	}
+
+	if got := e.Steps("ZC001"); len(got) != 2 || got[1].Step != 2 || got[1].RootOrderID != "ZC001" {
+		t.Errorf("Steps() = %+v", got)
+	}
+	if fmt.Sprint(adjusted) != "[1 2]" {
+		t.Errorf("Adjust 调用 = %v, want [1 2]", adjusted)
+	}
+	want := []string{"create:TEST001", "cancel:ZC001", "create:TEST001-R1", "cancel:ZC002", "create:TEST001-R2"}
+	if got := calls(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("接口调用 = %v, want %v", got, want)
+	}
+}
+
+func TestPriceEscalatorDelisted(t *testing.T) {
+	tracker, calls := newRepostTestTracker(t)
+	e, err := NewPriceEscalator(tracker, &EscalationConfig{Wait: time.Hour, StepPercent: "5", MaxAmount: "8000", MaxSteps: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Now()
+	e.now = func() time.Time { return clock }
+
+	for i := 0; i < 2; i++ {
+		req := newEscalationTestRequest()
+		req.OrderInfo.SelfComment = fmt.Sprintf("TEST%03d", i+1)
+		if _, err := e.CreateOrder(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// ZC001 在截止时间前被摘单
+	if err := tracker.Apply(&DelistNotification{OrderID: "ZC001", ConsignorState: ConsignorStateDelisted}); err != nil {
+		t.Fatal(err)
+	}
+
+	clock = clock.Add(time.Hour + time.Second)
+	steps := e.Check()
+	if len(steps) != 1 || steps[0].OrderID != "ZC002" || steps[0].ToAmount != "5250.00" {
+		t.Fatalf("Check() = %+v", steps)
+	}
+
+	// 加价次数用完
+	clock = clock.Add(time.Hour)
+	if steps := e.Check(); len(steps) != 0 {
+		t.Errorf("次数用完后 Check() = %v", steps)
+	}
+	want := []string{"create:TEST001", "create:TEST002", "cancel:ZC002", "create:TEST002-R1"}
+	if got := calls(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("接口调用 = %v, want %v", got, want)
+	}
+}
+
+func TestPriceEscalatorCancelRejected(t *testing.T) {
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		if method == MethodOrderCancel {
+			return &Response{Code: "1001", Message: "订单已摘单"}
+		}
+		return &Response{Code: "0000", Result: map[string]string{"orderId": "ZC001"}}
+	})
+	tracker, err := NewOrderTracker(client, NewMemoryOrderStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := NewPriceEscalator(tracker, &EscalationConfig{Wait: time.Hour, Step: "100", MaxAmount: "6000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+	if _, err := e.CreateOrder(newEscalationTestRequest()); err != nil {
+		t.Fatal(err)
+	}
+	steps := e.Check()
+	var apiErr *APIError
+	if len(steps) != 1 || !errors.As(steps[0].Err, &apiErr) || steps[0].NewOrderID != "" {
+		t.Fatalf("Check() = %+v, want APIError", steps)
+	}
+	// 平台拒绝取消时停止等待，避免重复取消
+	if got := e.Watching(); len(got) != 0 {
+		t.Errorf("Watching() = %v, want 空", got)
+	}
+}
+
+func TestPriceEscalatorRun(t *testing.T) {
+	tracker, _ := newRepostTestTracker(t)
+	e, err := NewPriceEscalator(tracker, &EscalationConfig{Wait: time.Hour, Step: "100", MaxAmount: "6000", Interval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := e.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() error = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestPriceEscalatorInvalidRepost(t *testing.T) {
+	tracker, calls := newRepostTestTracker(t)
+	e, err := NewPriceEscalator(tracker, &EscalationConfig{
+		Wait:      time.Hour,
+		Step:      "100",
+		MaxAmount: "6000",
+		Adjust:    func(req *CreateOrderRequest, step int) { req.CargoList = nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.now = func() time.Time { return time.Now().Add(time.Hour + time.Second) }
+
+	// 只填写承运方预估到手价、没有运费的订单无法加价
+	noAmount := newEscalationTestRequest()
+	noAmount.OrderInfo.SelfComment = "TEST002"
+	noAmount.OrderInfo.TotalAmount = ""
+	noAmount.OrderInfo.ConsignorNoTaxMoney = "4700.00"
+	for _, req := range []*CreateOrderRequest{newEscalationTestRequest(), noAmount} {
+		if _, err := e.CreateOrder(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps := e.Check()
+	if len(steps) != 2 {
+		t.Fatalf("Check() = %+v, want 2 次失败的加价", steps)
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i].OrderID < steps[j].OrderID })
+	var errs ValidationErrors
+	if s := steps[0]; s.OrderID != "ZC001" || s.ToAmount != "5100.00" || !errors.As(s.Err, &errs) || errs.Field("cargoList") == nil {
+		t.Errorf("ZC001 加价 = %+v, want 重新发布请求校验失败", s)
+	}
+	if s := steps[1]; s.OrderID != "ZC002" || s.ToAmount != "" || s.Err == nil {
+		t.Errorf("ZC002 加价 = %+v, want 运费为空错误", s)
+	}
+
+	// 新请求无效时不取消原订单
+	if got := e.Watching(); len(got) != 0 {
+		t.Errorf("Watching() = %v, want 空", got)
+	}
+	for _, id := range []string{"ZC001", "ZC002"} {
+		if record, _ := tracker.Get(id); record.Status != OrderStatusCreated {
+			t.Errorf("%s 状态 = %s, want created", id, record.Status)
+		}
+	}
+	if got := calls(); fmt.Sprint(got) != "[create:TEST001 create:TEST002]" {
+		t.Errorf("接口调用 = %v", got)
+	}
+}
+
+func TestPriceEscalatorInterceptPrice(t *testing.T) {
+	tracker, _ := newRepostTestTracker(t)
+	e, err := NewPriceEscalator(tracker, &EscalationConfig{Wait: time.Hour, Step: "300", MaxAmount: "6000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.now = func() time.Time { return time.Now().Add(time.Hour + time.Second) }
+
+	req := newEscalationTestRequest()
+	req.OrderInfo.InterceptPrice = "5200"
+	if _, err := e.CreateOrder(req); err != nil {
+		t.Fatal(err)
+	}
+	steps := e.Check()
+	if len(steps) != 1 || steps[0].Err != nil {
+		t.Fatalf("Check() = %+v", steps)
+	}
+	// 拦标价与运费同步提高300
+	created, _ := tracker.Get(steps[0].NewOrderID)
+	if info := created.Request.OrderInfo; info.TotalAmount != "5300.00" || info.InterceptPrice != "5500.00" {
+		t.Errorf("新订单运费 = %s，拦标价 = %s", info.TotalAmount, info.InterceptPrice)
+	}
+}
+
+func TestPriceEscalatorRepostOutcomeUnknown(t *testing.T) {
+	garbled := true
+	client, calls := newGarbledTestClient(t, &garbled)
+	tracker, err := NewOrderTracker(client, NewMemoryOrderStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := NewPriceEscalator(tracker, &EscalationConfig{Wait: time.Hour, Step: "100", MaxAmount: "6000", MaxRetries: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if _, err := e.CreateOrder(newEscalationTestRequest()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一次提交结果未知，保留等待
+	if steps := e.Check(); len(steps) != 1 || steps[0].Err == nil || steps[0].NewOrderID != "" {
+		t.Fatalf("Check() = %+v, want 重新发布失败", steps)
+	}
+	if got := e.Watching(); fmt.Sprint(got) != "[ZC001]" {
+		t.Fatalf("Watching() = %v, want [ZC001]", got)
+	}
+
+	// 再次检查不会重复提交，连续失败达到 MaxRetries 后停止等待
+	garbled = false
+	steps := e.Check()
+	if len(steps) != 1 || !errors.Is(steps[0].Err, ErrOrderOutcomeUnknown) {
+		t.Fatalf("Check() = %+v, want ErrOrderOutcomeUnknown", steps)
+	}
+	if got := e.Watching(); len(got) != 0 {
+		t.Errorf("Watching() = %v, want 空", got)
+	}
+	if steps := e.Check(); len(steps) != 0 {
+		t.Errorf("停止等待后 Check() = %+v", steps)
+	}
+	want := []string{"create:TEST001", "cancel:ZC001", "create:TEST001-R1"}
+	if got := calls(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("接口调用 = %v, want %v", got, want)
+	}
+}
+
+func TestPriceEscalatorAlreadyReposted(t *testing.T) {
+	tracker, calls := newRepostTestTracker(t)
+	e, err := NewPriceEscalator(tracker, &EscalationConfig{Wait: time.Hour, Step: "100", MaxAmount: "6000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if _, err := e.CreateOrder(newEscalationTestRequest()); err != nil {
+		t.Fatal(err)
+	}
+	// 人工确认已重新发布为 ZC009
+	if err := tracker.linkRepost("ZC001", "ZC009"); err != nil {
+		t.Fatal(err)
+	}
+
+	if steps := e.Check(); len(steps) != 0 {
+		t.Errorf("Check() = %+v, want 无加价", steps)
+	}
+	if got := e.Watching(); len(got) != 0 {
+		t.Errorf("Watching() = %v, want 空", got)
+	}
+	if got := calls(); fmt.Sprint(got) != "[create:TEST001]" {
+		t.Errorf("接口调用 = %v", got)
+	}
+}
+
+func TestPriceEscalatorRepostInProgress(t *testing.T) {
+	tracker, calls := newRepostTestTracker(t)
+	e, err := NewPriceEscalator(tracker, &EscalationConfig{Wait: time.Hour, Step: "100", MaxAmount: "6000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if _, err := e.CreateOrder(newEscalationTestRequest()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 过期清理或手动重新发布正在处理 ZC001 时跳过
+	tracker.acquireRepost("ZC001")
+	if steps := e.Check(); len(steps) != 0 {
+		t.Errorf("Check() = %+v, want 跳过", steps)
+	}
+	tracker.releaseRepost("ZC001")
+	if got := calls(); fmt.Sprint(got) != "[create:TEST001]" {
+		t.Errorf("接口调用 = %v", got)
+	}
+
+	if steps := e.Check(); len(steps) != 1 || steps[0].NewOrderID != "ZC002" {
+		t.Errorf("Check() = %+v, want 重新发布为 ZC002", steps)
+	}
+}
@@ -0,0 +1,390 @@
+package zczy
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrSelfCommentRequired 防重创建要求填写自定义单号
+	ErrSelfCommentRequired = errors.New("防重创建需要填写自定义单号selfComment")
+	// ErrOrderInProgress 相同自定义单号的订单正在创建中
+	ErrOrderInProgress = errors.New("相同自定义单号的订单正在创建中")
+	// ErrOrderOutcomeUnknown 无法确定平台是否已创建订单，需要对账后再提交
+	ErrOrderOutcomeUnknown = errors.New("无法确定订单是否已创建")
+)
+
+// LedgerState 台账中的订单创建状态
+type LedgerState string
+
+const (
+	// LedgerPending 已提交但结果未知（请求中、超时或响应无法解析）
+	LedgerPending LedgerState = "pending"
+	// LedgerCreated 平台已创建订单
+	LedgerCreated LedgerState = "created"
+	// LedgerFailed 平台明确拒绝，可以修改后重新提交
+	LedgerFailed LedgerState = "failed"
+)
+
+// LedgerEntry 以自定义单号为键的创建记录
+type LedgerEntry struct {
+	SelfComment string      `json:"selfComment"`         // 自定义单号
+	State       LedgerState `json:"state"`               // 创建状态
+	OrderID     string      `json:"orderId,omitempty"`   // 平台订单号
+	Attempts    int         `json:"attempts"`            // 提交次数
+	LastError   string      `json:"lastError,omitempty"` // 最后一次失败原因
+	CreatedAt   time.Time   `json:"createdAt"`           // 首次提交时间
+	UpdatedAt   time.Time   `json:"updatedAt"`           // 状态更新时间
+}
+
+// OrderLedger 订单创建台账，可以使用数据库等自行实现
+// 实现需要保证并发安全，Put 返回前记录必须已持久化
+type OrderLedger interface {
+	// Get 查询记录，不存在时返回 nil, nil
+	Get(selfComment string) (*LedgerEntry, error)
+	// Put 新增或覆盖记录
+	Put(entry *LedgerEntry) error
+	// List 按自定义单号顺序返回指定状态的记录，state 为空时返回全部
+	List(state LedgerState) ([]*LedgerEntry, error)
+}
+
+// MemoryOrderLedger 内存台账，进程重启后丢失，适用于测试
+type MemoryOrderLedger struct {
+	mu      sync.Mutex
+	entries map[string]*LedgerEntry
+}
+
+// NewMemoryOrderLedger 创建内存台账
+func NewMemoryOrderLedger() *MemoryOrderLedger {
+	return &MemoryOrderLedger{entries: make(map[string]*LedgerEntry)}
+}
+
+func (l *MemoryOrderLedger) Get(selfComment string) (*LedgerEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.entries[selfComment]; ok {
+		entry := *e
+		return &entry, nil
+	}
+	return nil, nil
+}
+
+func (l *MemoryOrderLedger) Put(entry *LedgerEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := *entry
+	l.entries[entry.SelfComment] = &e
+	return nil
+}
+
+func (l *MemoryOrderLedger) List(state LedgerState) ([]*LedgerEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return listLedgerEntries(l.entries, state), nil
+}
+
+// FileOrderLedger 文件台账，每次变更追加一行JSON并同步到磁盘，启动时以最后一行为准
+type FileOrderLedger struct {
+	mu      sync.Mutex
+	file    *os.File
+	entries map[string]*LedgerEntry
+}
+
+// OpenFileOrderLedger 打开文件台账，文件不存在时自动创建
+func OpenFileOrderLedger(path string) (*FileOrderLedger, error) {
+	entries := make(map[string]*LedgerEntry)
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+		for scanner.Scan() {
+			var e LedgerEntry
+			// 进程中断时最后一行可能不完整，跳过无法解析的行
+			if json.Unmarshal(scanner.Bytes(), &e) != nil || e.SelfComment == "" {
+				continue
+			}
+			entries[e.SelfComment] = &e
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("读取台账文件失败: %v", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("打开台账文件失败: %v", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("打开台账文件失败: %v", err)
+	}
+	return &FileOrderLedger{file: f, entries: entries}, nil
+}
+
+func (l *FileOrderLedger) Get(selfComment string) (*LedgerEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.entries[selfComment]; ok {
+		entry := *e
+		return &entry, nil
+	}
+	return nil, nil
+}
+
+func (l *FileOrderLedger) Put(entry *LedgerEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入台账文件失败: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("同步台账文件失败: %w", err)
+	}
+	e := *entry
+	l.entries[entry.SelfComment] = &e
+	return nil
+}
+
+func (l *FileOrderLedger) List(state LedgerState) ([]*LedgerEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return listLedgerEntries(l.entries, state), nil
+}
+
+// Close 关闭台账文件
+func (l *FileOrderLedger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// listLedgerEntries 按自定义单号顺序复制指定状态的记录
+func listLedgerEntries(entries map[string]*LedgerEntry, state LedgerState) []*LedgerEntry {
+	result := make([]*LedgerEntry, 0, len(entries))
+	for _, e := range entries {
+		if state == "" || e.State == state {
+			entry := *e
+			result = append(result, &entry)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].SelfComment < result[j].SelfComment })
+	return result
+}
+
+// OrderLookup 按自定义单号查询平台上是否已有订单，用于对账
+// 平台未提供按自定义单号查询的接口，可以对接货主后台导出、摘单回调记录等数据源
+type OrderLookup func(selfComment string) (orderID string, found bool, err error)
+
+// IdempotentOrderCreator 以 OrderInfo.SelfComment 为幂等键的订单创建器
+//
+// 每次提交前在台账中记录 pending，平台返回成功后记录 created，返回业务错误（*APIError）时记录 failed。
+// 网络超时等无法确定结果的情况保持 pending，再次创建时先通过 OrderLookup 对账：
+// 查到订单则直接返回，确认不存在才重新提交，未配置 OrderLookup 时返回 ErrOrderOutcomeUnknown。
+type IdempotentOrderCreator struct {
+	client *Client
+	ledger OrderLedger
+	lookup OrderLookup
+
+	mu       sync.Mutex
+	inflight map[string]bool
+}
+
+// NewIdempotentOrderCreator 创建防重订单创建器，lookup 可以为nil
+func NewIdempotentOrderCreator(client *Client, ledger OrderLedger, lookup OrderLookup) (*IdempotentOrderCreator, error) {
+	if client == nil {
+		return nil, errors.New("client is required")
+	}
+	if ledger == nil {
+		return nil, errors.New("ledger is required")
+	}
+	return &IdempotentOrderCreator{
+		client:   client,
+		ledger:   ledger,
+		lookup:   lookup,
+		inflight: make(map[string]bool),
+	}, nil
+}
+
+// Create 创建订单，相同自定义单号已创建成功时直接返回已有订单号，不会重复提交
+func (c *IdempotentOrderCreator) Create(req *CreateOrderRequest) (*CreateOrderResponse, error) {
+	key := strings.TrimSpace(req.OrderInfo.SelfComment)
+	if key == "" {
+		return nil, ErrSelfCommentRequired
+	}
+	if !c.acquire(key) {
+		return nil, ErrOrderInProgress
+	}
+	defer c.release(key)
+
+	entry, err := c.ledger.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("读取台账失败: %w", err)
+	}
+	if entry != nil && entry.State == LedgerPending {
+		if entry, err = c.reconcile(entry); err != nil {
+			return nil, err
+		}
+	}
+	if entry != nil && entry.State == LedgerCreated {
+		return &CreateOrderResponse{OrderID: entry.OrderID}, nil
+	}
+
+	now := time.Now()
+	if entry == nil {
+		entry = &LedgerEntry{SelfComment: key, CreatedAt: now}
+	}
+	entry.State = LedgerPending
+	entry.Attempts++
+	entry.UpdatedAt = now
+	if err := c.ledger.Put(entry); err != nil {
+		return nil, fmt.Errorf("写入台账失败: %w", err)
+	}
+
+	resp, createErr := c.client.CreateOrder(req)
+
+	var apiErr *APIError
+	switch {
+	case createErr == nil:
+		entry.State = LedgerCreated
+		entry.OrderID = resp.OrderID
+		entry.LastError = ""
+	case errors.As(createErr, &apiErr):
+		entry.State = LedgerFailed
+		entry.LastError = createErr.Error()
+	default:
+		entry.LastError = createErr.Error()
+	}
+	entry.UpdatedAt = time.Now()
+	if err := c.ledger.Put(entry); err != nil {
+		return resp, errors.Join(createErr, fmt.Errorf("写入台账失败: %w", err))
+	}
+
+	if entry.State == LedgerPending {
+		return nil, fmt.Errorf("%w: %w", ErrOrderOutcomeUnknown, createErr)
+	}
+	return resp, createErr
+}
+
+// Reconcile 对所有结果未知的记录执行对账，返回对账后的记录
+func (c *IdempotentOrderCreator) Reconcile() ([]*LedgerEntry, error) {
+	pending, err := c.ledger.List(LedgerPending)
+	if err != nil {
+		return nil, fmt.Errorf("读取台账失败: %w", err)
+	}
+
+	var errs []error
+	result := make([]*LedgerEntry, 0, len(pending))
+	for _, entry := range pending {
+		if !c.acquire(entry.SelfComment) {
+			continue
+		}
+		reconciled, err := c.reconcile(entry)
+		c.release(entry.SelfComment)
+		if err != nil && !errors.Is(err, ErrOrderOutcomeUnknown) {
+			errs = append(errs, err)
+			continue
+		}
+		result = append(result, reconciled)
+	}
+	return result, errors.Join(errs...)
+}
+
+// Resolve 人工确认结果未知的记录：orderID 不为空表示平台已创建，为空表示未创建、允许重新提交
+func (c *IdempotentOrderCreator) Resolve(selfComment, orderID string) error {
+	if !c.acquire(selfComment) {
+		return ErrOrderInProgress
+	}
+	defer c.release(selfComment)
+
+	entry, err := c.ledger.Get(selfComment)
+	if err != nil {
+		return fmt.Errorf("读取台账失败: %w", err)
+	}
+	if entry == nil {
+		entry = &LedgerEntry{SelfComment: selfComment, CreatedAt: time.Now()}
+	}
+	return c.settle(entry, orderID)
+}
+
+// HandleDelist 根据摘单回调中的自定义单号和订单号确认创建结果，可在回调处理中调用
+func (c *IdempotentOrderCreator) HandleDelist(n *DelistNotification) error {
+	if n.SelfComment == "" || n.OrderID == "" {
+		return nil
+	}
+	if !c.acquire(n.SelfComment) {
+		return ErrOrderInProgress
+	}
+	defer c.release(n.SelfComment)
+
+	entry, err := c.ledger.Get(n.SelfComment)
+	if err != nil {
+		return fmt.Errorf("读取台账失败: %w", err)
+	}
+	if entry == nil || entry.State == LedgerCreated {
+		return nil
+	}
+	return c.settle(entry, n.OrderID)
+}
+
+// reconcile 通过 OrderLookup 确认结果未知的记录
+func (c *IdempotentOrderCreator) reconcile(entry *LedgerEntry) (*LedgerEntry, error) {
+	if c.lookup == nil {
+		return entry, fmt.Errorf("%w: 自定义单号%s上次提交结果未知，请对账后调用Resolve", ErrOrderOutcomeUnknown, entry.SelfComment)
+	}
+
+	orderID, found, err := c.lookup(entry.SelfComment)
+	if err != nil {
+		return entry, fmt.Errorf("%w: 对账失败: %w", ErrOrderOutcomeUnknown, err)
+	}
+	if !found {
+		orderID = ""
+	}
+	if err := c.settle(entry, orderID); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// settle 记录确认后的结果：有订单号为已创建，否则为未创建
+func (c *IdempotentOrderCreator) settle(entry *LedgerEntry, orderID string) error {
+	if orderID != "" {
+		entry.State = LedgerCreated
+		entry.OrderID = orderID
+		entry.LastError = ""
+	} else {
+		entry.State = LedgerFailed
+		entry.LastError = "对账确认平台未创建订单"
+	}
+	entry.UpdatedAt = time.Now()
+	if err := c.ledger.Put(entry); err != nil {
+		return fmt.Errorf("写入台账失败: %w", err)
+	}
+	return nil
+}
+
+// acquire 占用自定义单号，已被占用时返回false
+func (c *IdempotentOrderCreator) acquire(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inflight[key] {
+		return false
+	}
+	c.inflight[key] = true
+	return true
+}
+
+func (c *IdempotentOrderCreator) release(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.inflight, key)
+}
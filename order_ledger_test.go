@@ -0,0 +1,130 @@
+package zczy
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestIdempotentOrderCreator(t *testing.T) {
+	calls := 0
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		calls++
+		return &Response{Code: "0000", Result: map[string]string{"orderId": "ZC001"}}
+	})
+	gateway := client.gateway
+
+	ledger := NewMemoryOrderLedger()
+	var lookupResult string
+	creator, err := NewIdempotentOrderCreator(client, ledger, func(selfComment string) (string, bool, error) {
+		return lookupResult, lookupResult != "", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := newValidCreateOrderRequest()
+
+	// 网络错误：结果未知，保持pending
+	client.SetGateway("http://127.0.0.1:1")
+	if _, err := creator.Create(req); !errors.Is(err, ErrOrderOutcomeUnknown) {
+		t.Fatalf("Create() error = %v, want ErrOrderOutcomeUnknown", err)
+	}
+	if e, _ := ledger.Get("TEST001"); e == nil || e.State != LedgerPending || e.Attempts != 1 {
+		t.Fatalf("ledger entry = %+v", e)
+	}
+
+	// 对账查到平台已创建：不再提交
+	client.SetGateway(gateway)
+	lookupResult = "ZC000"
+	resp, err := creator.Create(req)
+	if err != nil || resp.OrderID != "ZC000" || calls != 0 {
+		t.Fatalf("Create() = %+v, %v, calls=%d", resp, err, calls)
+	}
+
+	// 另一个订单：对账确认未创建后重新提交，之后重复调用直接返回
+	req.OrderInfo.SelfComment = "TEST002"
+	ledger.Put(&LedgerEntry{SelfComment: "TEST002", State: LedgerPending, Attempts: 1})
+	lookupResult = ""
+	for i := 0; i < 2; i++ {
+		resp, err = creator.Create(req)
+		if err != nil || resp.OrderID != "ZC001" {
+			t.Fatalf("Create() = %+v, %v", resp, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+	if e, _ := ledger.Get("TEST002"); e.State != LedgerCreated || e.Attempts != 2 {
+		t.Errorf("ledger entry = %+v", e)
+	}
+
+	req.OrderInfo.SelfComment = ""
+	if _, err := creator.Create(req); !errors.Is(err, ErrSelfCommentRequired) {
+		t.Errorf("Create() error = %v, want ErrSelfCommentRequired", err)
+	}
+}
+
+func TestIdempotentOrderCreatorAPIError(t *testing.T) {
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		return &Response{Code: "1001", Message: "参数错误"}
+	})
+	ledger := NewMemoryOrderLedger()
+	creator, _ := NewIdempotentOrderCreator(client, ledger, nil)
+
+	_, err := creator.Create(newValidCreateOrderRequest())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "1001" {
+		t.Fatalf("Create() error = %v, want *APIError", err)
+	}
+	if e, _ := ledger.Get("TEST001"); e.State != LedgerFailed {
+		t.Errorf("ledger entry = %+v", e)
+	}
+}
+
+func TestIdempotentOrderCreatorWithoutLookup(t *testing.T) {
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		return &Response{Code: "0000", Result: map[string]string{"orderId": "ZC001"}}
+	})
+	ledger := NewMemoryOrderLedger()
+	ledger.Put(&LedgerEntry{SelfComment: "TEST001", State: LedgerPending, Attempts: 1})
+	creator, _ := NewIdempotentOrderCreator(client, ledger, nil)
+
+	if _, err := creator.Create(newValidCreateOrderRequest()); !errors.Is(err, ErrOrderOutcomeUnknown) {
+		t.Fatalf("Create() error = %v, want ErrOrderOutcomeUnknown", err)
+	}
+
+	// 摘单回调带回订单号后确认创建结果
+	if err := creator.HandleDelist(&DelistNotification{SelfComment: "TEST001", OrderID: "ZC009"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := creator.Create(newValidCreateOrderRequest())
+	if err != nil || resp.OrderID != "ZC009" {
+		t.Errorf("Create() = %+v, %v", resp, err)
+	}
+}
+
+func TestFileOrderLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	ledger, err := OpenFileOrderLedger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger.Put(&LedgerEntry{SelfComment: "A", State: LedgerPending})
+	ledger.Put(&LedgerEntry{SelfComment: "B", State: LedgerPending})
+	ledger.Put(&LedgerEntry{SelfComment: "A", State: LedgerCreated, OrderID: "ZC001"})
+	ledger.Close()
+
+	reopened, err := OpenFileOrderLedger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	if e, _ := reopened.Get("A"); e == nil || e.State != LedgerCreated || e.OrderID != "ZC001" {
+		t.Errorf("Get(A) = %+v", e)
+	}
+	if pending, _ := reopened.List(LedgerPending); len(pending) != 1 || pending[0].SelfComment != "B" {
+		t.Errorf("List(pending) = %+v", pending)
+	}
+}
@@ -0,0 +1,151 @@
+package zczy
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTonnageOutOfTolerance 收货吨位与摘单吨位的差异超出允许范围
+var ErrTonnageOutOfTolerance = errors.New("收货吨位超出允许误差")
+
+// Validate 校验回单确认请求，返回所有问题
+// 规则：收货吨位必填且大于0，结算金额与承运方预估到手价必须且只能填写一项，是否提交结算申请为0或1
+func (r *ConfirmReceiptRequest) Validate() error {
+	v := &validator{}
+	v.required("orderId", r.OrderID)
+	if v.required("tonnage", string(r.Tonnage)) {
+		v.quantity("tonnage", r.Tonnage)
+		if d, err := r.Tonnage.Decimal(); err == nil && d.IsZero() {
+			v.addf("tonnage", "必须大于0")
+		}
+	}
+
+	v.money("settleMoney", r.SettleMoney)
+	v.money("consignorNoTaxMoney", r.ConsignorNoTaxMoney)
+	switch {
+	case r.SettleMoney == "" && r.ConsignorNoTaxMoney == "":
+		v.addf("settleMoney", "结算金额与承运方预估到手价必须填写一项")
+	case r.SettleMoney != "" && r.ConsignorNoTaxMoney != "":
+		v.addf("settleMoney", "结算金额与承运方预估到手价只能填写一项")
+	}
+
+	if v.required("settleApplyFlag", r.SettleApplyFlag) {
+		v.oneOf("settleApplyFlag", r.SettleApplyFlag, "0", "1")
+	}
+	return v.err()
+}
+
+// TonnageTolerance 收货吨位相对摘单吨位的允许误差，两项都填写时满足任一项即可
+type TonnageTolerance struct {
+	Percent string   // 按摘单吨位的百分比，例如 "0.5" 表示 0.5%
+	Tons    Quantity // 按绝对吨数，例如 "0.2"
+}
+
+// TonnageDeviationError 收货吨位超出允许误差
+type TonnageDeviationError struct {
+	Delisted  Quantity // 摘单吨位
+	Received  Quantity // 收货吨位
+	Deviation Decimal  // 收货吨位 - 摘单吨位，负数表示亏吨
+	Allowed   Decimal  // 允许的最大误差（吨）
+}
+
+func (e *TonnageDeviationError) Error() string {
+	return fmt.Sprintf("%v: 摘单%s吨，收货%s吨，差异%s吨，允许误差%s吨",
+		ErrTonnageOutOfTolerance, e.Delisted, e.Received, e.Deviation, e.Allowed)
+}
+
+func (e *TonnageDeviationError) Unwrap() error {
+	return ErrTonnageOutOfTolerance
+}
+
+// Allowed 返回指定摘单吨位下允许的最大误差（吨）
+// 摘单吨位为空或误差为负数时返回错误
+func (t *TonnageTolerance) Allowed(delisted Quantity) (Decimal, error) {
+	weight, err := delistedWeight(delisted)
+	if err != nil {
+		return Decimal{}, err
+	}
+
+	var allowed Decimal
+	if t.Percent != "" {
+		percent, err := ParseDecimal(t.Percent)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("误差百分比格式错误: %w", err)
+		}
+		if percent.Sign() < 0 {
+			return Decimal{}, fmt.Errorf("误差百分比不能为负数: %s", t.Percent)
+		}
+		allowed = weight.Percent(percent)
+	}
+	if t.Tons != "" {
+		tons, err := t.Tons.Decimal()
+		if err != nil {
+			return Decimal{}, fmt.Errorf("误差吨数格式错误: %w", err)
+		}
+		if tons.Sign() < 0 {
+			return Decimal{}, fmt.Errorf("误差吨数不能为负数: %s", t.Tons)
+		}
+		allowed = allowed.Max(tons)
+	}
+	return allowed, nil
+}
+
+// delistedWeight 解析摘单吨位，未填写时返回错误而不是按0吨比较
+func delistedWeight(delisted Quantity) (Decimal, error) {
+	if delisted == "" {
+		return Decimal{}, errors.New("摘单吨位为空，无法比较收货吨位")
+	}
+	weight, err := delisted.Decimal()
+	if err != nil {
+		return Decimal{}, fmt.Errorf("摘单吨位格式错误: %w", err)
+	}
+	return weight, nil
+}
+
+// Check 比较收货吨位与摘单吨位，超出允许误差时返回 *TonnageDeviationError
+func (t *TonnageTolerance) Check(delisted, received Quantity) error {
+	allowed, err := t.Allowed(delisted)
+	if err != nil {
+		return err
+	}
+	weight, err := delistedWeight(delisted)
+	if err != nil {
+		return err
+	}
+	tonnage, err := received.Decimal()
+	if err != nil {
+		return fmt.Errorf("收货吨位格式错误: %w", err)
+	}
+
+	deviation := tonnage.Sub(weight)
+	diff := deviation
+	if diff.Sign() < 0 {
+		diff = diff.Neg()
+	}
+	if diff.Cmp(allowed) > 0 {
+		return &TonnageDeviationError{
+			Delisted:  delisted,
+			Received:  received,
+			Deviation: deviation,
+			Allowed:   allowed,
+		}
+	}
+	return nil
+}
+
+// ConfirmReceiptValidated 校验后回单确认，校验不通过时不会调用平台接口
+// delist 为该订单的摘单通知，与 tolerance 都不为nil时比较收货吨位与摘单吨位
+func (c *Client) ConfirmReceiptValidated(req *ConfirmReceiptRequest, delist *DelistNotification, tolerance *TonnageTolerance) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	if delist != nil && tolerance != nil {
+		if delist.OrderID != "" && delist.OrderID != req.OrderID {
+			return fmt.Errorf("摘单通知的订单号%s与回单确认的订单号%s不一致", delist.OrderID, req.OrderID)
+		}
+		if err := tolerance.Check(delist.Weight, req.Tonnage); err != nil {
+			return err
+		}
+	}
+	return c.ConfirmReceipt(req)
+}
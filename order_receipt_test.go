@@ -0,0 +1,103 @@
+package zczy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConfirmReceiptRequestValidate(t *testing.T) {
+	valid := ConfirmReceiptRequest{OrderID: "ZC001", Tonnage: "30", SettleMoney: "5000", SettleApplyFlag: "0"}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(r *ConfirmReceiptRequest)
+		wantPath string
+	}{
+		{"缺少吨位", func(r *ConfirmReceiptRequest) { r.Tonnage = "" }, "tonnage"},
+		{"吨位为0", func(r *ConfirmReceiptRequest) { r.Tonnage = "0" }, "tonnage"},
+		{"金额都未填写", func(r *ConfirmReceiptRequest) { r.SettleMoney = "" }, "settleMoney"},
+		{"金额同时填写", func(r *ConfirmReceiptRequest) { r.ConsignorNoTaxMoney = "4800" }, "settleMoney"},
+		{"结算申请标志错误", func(r *ConfirmReceiptRequest) { r.SettleApplyFlag = "是" }, "settleApplyFlag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+			var errs ValidationErrors
+			if err := req.Validate(); !errors.As(err, &errs) || errs.Field(tt.wantPath) == nil {
+				t.Errorf("Validate() error = %v, want %s", err, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestTonnageToleranceCheck(t *testing.T) {
+	tolerance := &TonnageTolerance{Percent: "0.5", Tons: "0.1"}
+
+	// 30吨的0.5%为0.15吨，大于0.1吨
+	if err := tolerance.Check("30", "29.85"); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+	err := tolerance.Check("30", "29.8")
+	var dev *TonnageDeviationError
+	if !errors.As(err, &dev) || !errors.Is(err, ErrTonnageOutOfTolerance) {
+		t.Fatalf("Check() error = %v, want *TonnageDeviationError", err)
+	}
+	if dev.Deviation.String() != "-0.2" || dev.Allowed.Cmp(MustParseDecimal("0.15")) != 0 {
+		t.Errorf("Deviation = %s, Allowed = %s", dev.Deviation, dev.Allowed)
+	}
+
+	// 10吨的0.5%为0.05吨，小于0.1吨
+	if err := tolerance.Check("10", "10.1"); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+}
+
+func TestTonnageToleranceInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		tolerance TonnageTolerance
+		delisted  Quantity
+	}{
+		{"摘单吨位为空", TonnageTolerance{Tons: "0.5"}, ""},
+		{"误差百分比为负数", TonnageTolerance{Percent: "-0.5"}, "30"},
+		{"误差吨数为负数", TonnageTolerance{Tons: "-0.2"}, "30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.tolerance.Allowed(tt.delisted); err == nil {
+				t.Error("Allowed() error = nil, want error")
+			}
+			err := tt.tolerance.Check(tt.delisted, "0.3")
+			if err == nil || errors.Is(err, ErrTonnageOutOfTolerance) {
+				t.Errorf("Check() error = %v, want input error", err)
+			}
+		})
+	}
+}
+
+func TestConfirmReceiptValidated(t *testing.T) {
+	calls := 0
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		calls++
+		return &Response{Code: "0000"}
+	})
+	delist := &DelistNotification{OrderID: "ZC001", Weight: "30"}
+	tolerance := &TonnageTolerance{Tons: "0.5"}
+
+	req := &ConfirmReceiptRequest{OrderID: "ZC001", Tonnage: "28", SettleMoney: "5000", SettleApplyFlag: "1"}
+	if err := client.ConfirmReceiptValidated(req, delist, tolerance); !errors.Is(err, ErrTonnageOutOfTolerance) {
+		t.Errorf("ConfirmReceiptValidated() error = %v, want ErrTonnageOutOfTolerance", err)
+	}
+
+	req.Tonnage = "29.6"
+	if err := client.ConfirmReceiptValidated(req, delist, tolerance); err != nil {
+		t.Errorf("ConfirmReceiptValidated() error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
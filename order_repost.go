@@ -0,0 +1,314 @@
+package zczy
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrOrderNotRepostable 订单当前状态不能重新发布
+	ErrOrderNotRepostable = errors.New("订单当前状态不能重新发布")
+	// ErrOrderNotTracked 跟踪器中没有该订单的记录或创建请求
+	ErrOrderNotTracked = errors.New("未找到订单记录或创建请求")
+)
+
+// RepostAdjustment 重新发布订单时的调整，零值字段表示沿用原订单
+type RepostAdjustment struct {
+	Shift         time.Duration // 报价结束、装货开始/结束、收货时间整体平移
+	DespatchStart PlatformTime  // 新的装货开始时间，优先于 Shift
+	DespatchEnd   PlatformTime  // 新的装货结束时间，优先于 Shift
+	ReceiveDate   PlatformTime  // 新的收货时间，优先于 Shift
+	ExpectTime    PlatformTime  // 新的报价结束时间，优先于 Shift
+
+	// TotalAmount 新的运费；只调整运费时清空原承运方预估到手价，避免两者不一致
+	TotalAmount         Money
+	ConsignorNoTaxMoney Money // 新的承运方预估到手价
+	InterceptPrice      Money // 新的拦标价
+
+	// Modify 在上述调整之后执行的其他修改
+	Modify func(req *CreateOrderRequest)
+}
+
+// repostSuffix 匹配自定义单号中的重新发布代数，例如 TEST001-R2
+var repostSuffix = regexp.MustCompile(`^(.*)-R([1-9][0-9]*)$`)
+
+// RepostLineage 解析自定义单号，返回原始单号和重新发布代数（原始订单为0）
+func RepostLineage(selfComment string) (base string, generation int) {
+	m := repostSuffix.FindStringSubmatch(selfComment)
+	if m == nil {
+		return selfComment, 0
+	}
+	generation, _ = strconv.Atoi(m[2])
+	return m[1], generation
+}
+
+// RepostSelfComment 返回下一代自定义单号：TEST001 → TEST001-R1 → TEST001-R2
+func RepostSelfComment(selfComment string) string {
+	base, generation := RepostLineage(selfComment)
+	return fmt.Sprintf("%s-R%d", base, generation+1)
+}
+
+// PrepareRepost 复制原订单请求，应用调整并生成下一代自定义单号，返回校验通过的新请求，不修改原请求
+func PrepareRepost(req *CreateOrderRequest, adj *RepostAdjustment) (*CreateOrderRequest, error) {
+	if req.OrderInfo.SelfComment == "" {
+		return nil, ErrSelfCommentRequired
+	}
+	if adj == nil {
+		adj = &RepostAdjustment{}
+	}
+
+	next := req.clone()
+	info := &next.OrderInfo
+	info.SelfComment = RepostSelfComment(req.OrderInfo.SelfComment)
+
+	for _, f := range []struct {
+		field *PlatformTime
+		value PlatformTime
+	}{
+		{&info.ExpectTime, adj.ExpectTime},
+		{&info.DespatchStart, adj.DespatchStart},
+		{&info.DespatchEnd, adj.DespatchEnd},
+		{&info.ReceiveDate, adj.ReceiveDate},
+	} {
+		switch {
+		case !f.value.IsZero():
+			*f.field = f.value
+		case adj.Shift != 0 && !f.field.IsZero():
+			t, err := f.field.Time()
+			if err != nil {
+				return nil, err
+			}
+			*f.field = NewPlatformTime(t.Add(adj.Shift))
+		}
+	}
+
+	if adj.TotalAmount != "" {
+		info.TotalAmount = adj.TotalAmount
+		info.ConsignorNoTaxMoney = ""
+	}
+	if adj.ConsignorNoTaxMoney != "" {
+		info.ConsignorNoTaxMoney = adj.ConsignorNoTaxMoney
+	}
+	if adj.InterceptPrice != "" {
+		info.InterceptPrice = adj.InterceptPrice
+	}
+	if adj.Modify != nil {
+		adj.Modify(next)
+	}
+
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// RepostResult 重新发布结果
+type RepostResult struct {
+	OriginalOrderID string              // 原订单号（仅通过 OrderTracker 重新发布时有值）
+	OrderID         string              // 新订单号
+	Request         *CreateOrderRequest // 新订单的创建请求
+}
+
+// RepostOrder 按原订单请求重新发布，原订单需已取消或过期，由调用方保证
+func (c *Client) RepostOrder(req *CreateOrderRequest, adj *RepostAdjustment) (*RepostResult, error) {
+	next, err := PrepareRepost(req, adj)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.CreateOrder(next)
+	if err != nil {
+		return nil, err
+	}
+	return &RepostResult{OrderID: resp.OrderID, Request: next}, nil
+}
+
+// Repost 重新发布跟踪器中的订单，并在新旧订单记录中互相关联
+//
+// 可以重新发布的订单：已取消；或仍为已创建状态但装货结束时间已过（过期），此时先取消原订单。
+// 每个订单只能重新发布一次，再次发布请对新订单调用 Repost。
+// 创建新订单前在原订单记录中登记新的自定义单号（RepostPending），上次重新发布中断后再次调用时，
+// 先按该单号查找已创建的新订单并补全关联。本地找不到时平台可能已经创建（例如超时或响应无法解析），
+// 未设置 SetCreator 时返回 ErrOrderOutcomeUnknown，确认结果后调用 ResolveRepost；
+// 设置后交给 IdempotentOrderCreator 对账，确认未创建才重新提交
+func (t *OrderTracker) Repost(orderID string, adj *RepostAdjustment) (*RepostResult, error) {
+	return t.repost(orderID, func(req *CreateOrderRequest) (*CreateOrderRequest, error) {
+		return PrepareRepost(req, adj)
+	})
+}
+
+// repost 执行重新发布，prepare 根据原订单请求生成新请求
+func (t *OrderTracker) repost(orderID string, prepare func(req *CreateOrderRequest) (*CreateOrderRequest, error)) (*RepostResult, error) {
+	if !t.acquireRepost(orderID) {
+		return nil, fmt.Errorf("%w: %s", ErrOrderInProgress, orderID)
+	}
+	defer t.releaseRepost(orderID)
+	return t.repostLocked(orderID, prepare)
+}
+
+// repostLocked 执行重新发布，调用方需已通过 acquireRepost 占用 orderID
+func (t *OrderTracker) repostLocked(orderID string, prepare func(req *CreateOrderRequest) (*CreateOrderRequest, error)) (*RepostResult, error) {
+	record, err := t.store.Get(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("读取订单记录失败: %w", err)
+	}
+	if record == nil || record.Request == nil {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotTracked, orderID)
+	}
+	if record.RepostedAs != "" {
+		return nil, fmt.Errorf("%w: 已重新发布为%s", ErrOrderNotRepostable, record.RepostedAs)
+	}
+	if record.RepostPending != "" {
+		created, err := t.findRepost(orderID, record.RepostPending)
+		if err != nil {
+			return nil, err
+		}
+		if created != nil {
+			result := &RepostResult{OriginalOrderID: orderID, OrderID: created.OrderID, Request: created.Request}
+			return result, t.linkRepost(orderID, created.OrderID)
+		}
+		if t.creator == nil {
+			return nil, fmt.Errorf("%w: %s 上次重新发布的自定义单号%s提交结果未知，请确认后调用ResolveRepost",
+				ErrOrderOutcomeUnknown, orderID, record.RepostPending)
+		}
+	}
+
+	next, err := prepare(record.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case record.Status == OrderStatusCancelled:
+	case record.Status == OrderStatusCreated && orderExpired(record.Request, time.Now()):
+		if err := t.CancelOrder(orderID); err != nil {
+			return nil, fmt.Errorf("取消过期订单失败: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s 当前状态为%s", ErrOrderNotRepostable, orderID, record.Status)
+	}
+
+	pending := next.OrderInfo.SelfComment
+	if err := t.setRepostPending(orderID, pending); err != nil {
+		return nil, err
+	}
+	resp, err := t.CreateOrder(next)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			// 平台明确拒绝，新订单未创建
+			if clearErr := t.setRepostPending(orderID, ""); clearErr != nil {
+				return nil, errors.Join(err, clearErr)
+			}
+		}
+		return nil, err
+	}
+	result := &RepostResult{OriginalOrderID: orderID, OrderID: resp.OrderID, Request: next}
+	return result, t.linkRepost(orderID, resp.OrderID)
+}
+
+// ResolveRepost 人工确认结果未知的重新发布：newOrderID 不为空表示平台已创建新订单，在新旧订单记录中关联；
+// 为空表示未创建，清除登记后可以再次调用 Repost。设置了 SetCreator 时同步确认台账
+func (t *OrderTracker) ResolveRepost(orderID, newOrderID string) error {
+	if !t.acquireRepost(orderID) {
+		return fmt.Errorf("%w: %s", ErrOrderInProgress, orderID)
+	}
+	defer t.releaseRepost(orderID)
+
+	record, err := t.store.Get(orderID)
+	if err != nil {
+		return fmt.Errorf("读取订单记录失败: %w", err)
+	}
+	if record == nil || record.RepostPending == "" {
+		return fmt.Errorf("订单%s没有结果未知的重新发布", orderID)
+	}
+	pending := record.RepostPending
+	if t.creator != nil {
+		if err := t.creator.Resolve(pending, newOrderID); err != nil {
+			return err
+		}
+	}
+	if newOrderID == "" {
+		return t.setRepostPending(orderID, "")
+	}
+	if err := t.update(newOrderID, pending, func(r *OrderRecord, now time.Time) error {
+		if r.Status == "" {
+			r.transition(OrderStatusCreated, "api", "人工确认重新发布已创建", now)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return t.linkRepost(orderID, newOrderID)
+}
+
+// setRepostPending 登记或清除正在重新发布的自定义单号
+func (t *OrderTracker) setRepostPending(orderID, selfComment string) error {
+	return t.update(orderID, "", func(r *OrderRecord, now time.Time) error {
+		r.RepostPending = selfComment
+		r.UpdatedAt = now
+		return nil
+	})
+}
+
+// findRepost 按自定义单号查找由 orderID 重新发布的订单记录，未找到时返回nil
+func (t *OrderTracker) findRepost(orderID, selfComment string) (*OrderRecord, error) {
+	records, err := t.FindBySelfComment(selfComment)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单记录失败: %w", err)
+	}
+	for _, r := range records {
+		if r.OrderID != orderID && r.Request != nil && (r.RepostOf == "" || r.RepostOf == orderID) {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+// linkRepost 在新旧订单记录中互相关联，并清除原订单的 RepostPending
+func (t *OrderTracker) linkRepost(orderID, newOrderID string) error {
+	if err := t.update(newOrderID, "", func(r *OrderRecord, now time.Time) error {
+		r.RepostOf = orderID
+		r.UpdatedAt = now
+		return nil
+	}); err != nil {
+		return err
+	}
+	return t.update(orderID, "", func(r *OrderRecord, now time.Time) error {
+		r.RepostedAs = newOrderID
+		r.RepostPending = ""
+		r.History = append(r.History, OrderHistoryEntry{
+			At:     now,
+			Status: r.Status,
+			Source: "api",
+			Note:   "重新发布为" + newOrderID,
+		})
+		r.UpdatedAt = now
+		return nil
+	})
+}
+
+// orderExpired 判断订单装货结束时间是否已过，未填写或格式错误时返回false
+func orderExpired(req *CreateOrderRequest, now time.Time) bool {
+	end, err := req.OrderInfo.DespatchEnd.Time()
+	return err == nil && !req.OrderInfo.DespatchEnd.IsZero() && end.Before(now)
+}
+
+// acquireRepost 占用订单的重新发布操作，已被占用时返回false
+func (t *OrderTracker) acquireRepost(orderID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.reposting[orderID] {
+		return false
+	}
+	t.reposting[orderID] = true
+	return true
+}
+
+func (t *OrderTracker) releaseRepost(orderID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.reposting, orderID)
+}
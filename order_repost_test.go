@@ -0,0 +1,397 @@
+package zczy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRepostSelfComment(t *testing.T) {
+	tests := []struct {
+		input, want string
+		generation  int
+	}{
+		{"TEST001", "TEST001-R1", 0},
+		{"TEST001-R1", "TEST001-R2", 1},
+		{"TEST001-R9", "TEST001-R10", 9},
+		{"A-R0", "A-R0-R1", 0},
+		{"A-RB", "A-RB-R1", 0},
+	}
+	for _, tt := range tests {
+		if got := RepostSelfComment(tt.input); got != tt.want {
+			t.Errorf("RepostSelfComment(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+		if _, generation := RepostLineage(tt.input); generation != tt.generation {
+			t.Errorf("RepostLineage(%q) generation = %d, want %d", tt.input, generation, tt.generation)
+		}
+	}
+}
+
+func TestPrepareRepost(t *testing.T) {
+	orig := newValidCreateOrderRequest()
+	orig.OrderInfo.ConsignorNoTaxMoney = "4700.00"
+
+	next, err := PrepareRepost(orig, &RepostAdjustment{
+		Shift:          48 * time.Hour,
+		ReceiveDate:    "2025-01-28 18:00",
+		TotalAmount:    "5200.00",
+		InterceptPrice: "5800",
+		Modify: func(req *CreateOrderRequest) {
+			req.CargoList[0].CargoName = "螺纹钢"
+		},
+	})
+	if err != nil {
+		t.Fatalf("PrepareRepost() error = %v", err)
+	}
+
+	info := next.OrderInfo
+	if info.SelfComment != "TEST001-R1" {
+		t.Errorf("SelfComment = %s, want TEST001-R1", info.SelfComment)
+	}
+	if info.DespatchStart != "2025-01-22 08:00" || info.DespatchEnd != "2025-01-22 12:00" || info.ReceiveDate != "2025-01-28 18:00" {
+		t.Errorf("时间 = %s %s %s", info.DespatchStart, info.DespatchEnd, info.ReceiveDate)
+	}
+	if info.TotalAmount != "5200.00" || info.ConsignorNoTaxMoney != "" || info.InterceptPrice != "5800" {
+		t.Errorf("价格 = %s %s %s", info.TotalAmount, info.ConsignorNoTaxMoney, info.InterceptPrice)
+	}
+
+	if orig.OrderInfo.SelfComment != "TEST001" || orig.CargoList[0].CargoName != "钢材" || orig.OrderInfo.TotalAmount != "5000.00" {
+		t.Error("PrepareRepost 不应修改原请求")
+	}
+
+	// 调整后校验不通过
+	_, err = PrepareRepost(orig, &RepostAdjustment{DespatchStart: "2025-01-30 08:00"})
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Errorf("PrepareRepost() error = %v, want ValidationErrors", err)
+	}
+}
+
+// newRepostTestTracker 返回跟踪器及按顺序记录的接口调用
+func newRepostTestTracker(t *testing.T) (*OrderTracker, func() []string) {
+	var mu sync.Mutex
+	var calls []string
+	n := 0
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		mu.Lock()
+		defer mu.Unlock()
+		switch method {
+		case MethodOrderCreateMore:
+			var req CreateOrderRequest
+			json.Unmarshal([]byte(params), &req)
+			n++
+			calls = append(calls, "create:"+req.OrderInfo.SelfComment)
+			return &Response{Code: "0000", Result: map[string]string{"orderId": fmt.Sprintf("ZC%03d", n)}}
+		case MethodOrderCancel:
+			var req CancelOrderRequest
+			json.Unmarshal([]byte(params), &req)
+			calls = append(calls, "cancel:"+req.OrderID)
+		}
+		return &Response{Code: "0000"}
+	})
+
+	tracker, err := NewOrderTracker(client, NewMemoryOrderStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tracker, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), calls...)
+	}
+}
+
+func TestOrderTrackerRepostCancelled(t *testing.T) {
+	tracker, calls := newRepostTestTracker(t)
+
+	req := newValidCreateOrderRequest()
+	req.OrderInfo.DespatchEnd = NewPlatformTime(time.Now().Add(time.Hour))
+	req.OrderInfo.DespatchStart = NewPlatformTime(time.Now().Add(-time.Hour))
+	req.OrderInfo.ReceiveDate = NewPlatformTime(time.Now().Add(48 * time.Hour))
+	if _, err := tracker.CreateOrder(req); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未过期且未取消的订单不能重新发布
+	if _, err := tracker.Repost("ZC001", nil); !errors.Is(err, ErrOrderNotRepostable) {
+		t.Fatalf("Repost() error = %v, want ErrOrderNotRepostable", err)
+	}
+
+	if err := tracker.CancelOrder("ZC001"); err != nil {
+		t.Fatal(err)
+	}
+	result, err := tracker.Repost("ZC001", &RepostAdjustment{TotalAmount: "5500.00"})
+	if err != nil {
+		t.Fatalf("Repost() error = %v", err)
+	}
+	if result.OrderID != "ZC002" || result.OriginalOrderID != "ZC001" || result.Request.OrderInfo.SelfComment != "TEST001-R1" {
+		t.Errorf("Repost() = %+v", result)
+	}
+
+	old, _ := tracker.Get("ZC001")
+	created, _ := tracker.Get("ZC002")
+	if old.RepostedAs != "ZC002" || created.RepostOf != "ZC001" {
+		t.Errorf("RepostedAs = %s, RepostOf = %s", old.RepostedAs, created.RepostOf)
+	}
+	if created.Request.OrderInfo.TotalAmount != "5500.00" || created.Status != OrderStatusCreated {
+		t.Errorf("新订单记录 = %+v", created)
+	}
+
+	if _, err := tracker.Repost("ZC001", nil); !errors.Is(err, ErrOrderNotRepostable) {
+		t.Errorf("重复 Repost() error = %v, want ErrOrderNotRepostable", err)
+	}
+	if _, err := tracker.Repost("ZC999", nil); !errors.Is(err, ErrOrderNotTracked) {
+		t.Errorf("Repost(未跟踪) error = %v, want ErrOrderNotTracked", err)
+	}
+
+	want := []string{"create:TEST001", "cancel:ZC001", "create:TEST001-R1"}
+	if got := calls(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("接口调用 = %v, want %v", got, want)
+	}
+}
+
+func TestOrderTrackerRepostExpired(t *testing.T) {
+	tracker, calls := newRepostTestTracker(t)
+
+	// 装货结束时间已过，重新发布前先取消原订单
+	if _, err := tracker.CreateOrder(newValidCreateOrderRequest()); err != nil {
+		t.Fatal(err)
+	}
+	result, err := tracker.Repost("ZC001", &RepostAdjustment{Shift: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Repost() error = %v", err)
+	}
+	if result.Request.OrderInfo.DespatchEnd != "2025-01-21 12:00" {
+		t.Errorf("DespatchEnd = %s", result.Request.OrderInfo.DespatchEnd)
+	}
+
+	old, _ := tracker.Get("ZC001")
+	if old.Status != OrderStatusCancelled {
+		t.Errorf("原订单状态 = %s, want cancelled", old.Status)
+	}
+	want := []string{"create:TEST001", "cancel:ZC001", "create:TEST001-R1"}
+	if got := calls(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("接口调用 = %v, want %v", got, want)
+	}
+}
+
+func TestOrderTrackerRepostPending(t *testing.T) {
+	tracker, calls := newRepostTestTracker(t)
+	if _, err := tracker.CreateOrder(newValidCreateOrderRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.CancelOrder("ZC001"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟上次重新发布在新订单创建后、关联前中断
+	record, _ := tracker.Get("ZC001")
+	next, err := PrepareRepost(record.Request, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.setRepostPending("ZC001", next.OrderInfo.SelfComment); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tracker.CreateOrder(next); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := tracker.Repost("ZC001", &RepostAdjustment{
+		Modify: func(req *CreateOrderRequest) { req.CargoList[0].CargoName = "螺纹钢" },
+	})
+	if err != nil {
+		t.Fatalf("Repost() error = %v", err)
+	}
+	if result.OrderID != "ZC002" || result.Request.CargoList[0].CargoName != "钢材" {
+		t.Errorf("Repost() = %+v, want 关联已创建的 ZC002", result)
+	}
+	old, _ := tracker.Get("ZC001")
+	created, _ := tracker.Get("ZC002")
+	if old.RepostedAs != "ZC002" || old.RepostPending != "" || created.RepostOf != "ZC001" {
+		t.Errorf("RepostedAs = %s, RepostPending = %s, RepostOf = %s", old.RepostedAs, old.RepostPending, created.RepostOf)
+	}
+	want := []string{"create:TEST001", "cancel:ZC001", "create:TEST001-R1"}
+	if got := calls(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("接口调用 = %v, want %v", got, want)
+	}
+}
+
+func TestOrderTrackerRepostRejected(t *testing.T) {
+	reject := true
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		if method == MethodOrderCreateMore && strings.Contains(params, "TEST001-R1") && reject {
+			return &Response{Code: "1001", Message: "运费不能低于拦标价"}
+		}
+		return &Response{Code: "0000", Result: map[string]string{"orderId": "ZC001"}}
+	})
+	tracker, err := NewOrderTracker(client, NewMemoryOrderStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tracker.CreateOrder(newValidCreateOrderRequest()); err != nil {
+		t.Fatal(err)
+	}
+
+	var modified *CreateOrderRequest
+	_, err = tracker.Repost("ZC001", &RepostAdjustment{
+		Modify: func(req *CreateOrderRequest) {
+			req.CargoList[0].CargoName = "螺纹钢"
+			modified = req
+		},
+	})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Repost() error = %v, want APIError", err)
+	}
+	// 平台拒绝后清除登记，Modify 的修改不影响已保存的原请求
+	record, _ := tracker.Get("ZC001")
+	if record.RepostPending != "" || record.Request.CargoList[0].CargoName != "钢材" || modified.CargoList[0].CargoName != "螺纹钢" {
+		t.Errorf("原订单记录 = %+v", record)
+	}
+}
+
+// newGarbledTestClient 返回测试客户端，订单号依次为 ZC001…，TEST001-R1 的创建响应在 garbled 为true时无法解析
+func newGarbledTestClient(t *testing.T, garbled *bool) (*Client, func() []string) {
+	var mu sync.Mutex
+	var calls []string
+	n := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Form.Get("method") {
+		case MethodOrderCreateMore:
+			var req CreateOrderRequest
+			json.Unmarshal([]byte(r.Form.Get("params")), &req)
+			n++
+			calls = append(calls, "create:"+req.OrderInfo.SelfComment)
+			if *garbled && req.OrderInfo.SelfComment == "TEST001-R1" {
+				w.Write([]byte("<html>502 Bad Gateway</html>"))
+				return
+			}
+		case MethodOrderCancel:
+			var req CancelOrderRequest
+			json.Unmarshal([]byte(r.Form.Get("params")), &req)
+			calls = append(calls, "cancel:"+req.OrderID)
+		}
+		json.NewEncoder(w).Encode(&Response{Code: "0000", Result: map[string]string{"orderId": fmt.Sprintf("ZC%03d", n)}})
+	}))
+	t.Cleanup(server.Close)
+
+	client := newTestAPIClient(t, nil)
+	client.SetGateway(server.URL)
+	return client, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), calls...)
+	}
+}
+
+// newGarbledRepostTracker 创建 ZC001 并取消，之后重新发布 TEST001-R1 的响应在 garbled 为true时无法解析
+func newGarbledRepostTracker(t *testing.T, garbled *bool) (*OrderTracker, func() []string) {
+	client, calls := newGarbledTestClient(t, garbled)
+	tracker, err := NewOrderTracker(client, NewMemoryOrderStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tracker.CreateOrder(newValidCreateOrderRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.CancelOrder("ZC001"); err != nil {
+		t.Fatal(err)
+	}
+	return tracker, calls
+}
+
+func TestOrderTrackerRepostOutcomeUnknown(t *testing.T) {
+	garbled := true
+	tracker, calls := newGarbledRepostTracker(t, &garbled)
+
+	if _, err := tracker.Repost("ZC001", nil); err == nil {
+		t.Fatal("Repost() error = nil, want error")
+	}
+	// 平台可能已创建 TEST001-R1，不能直接重新提交
+	garbled = false
+	if _, err := tracker.Repost("ZC001", nil); !errors.Is(err, ErrOrderOutcomeUnknown) {
+		t.Fatalf("Repost() error = %v, want ErrOrderOutcomeUnknown", err)
+	}
+	if want := []string{"create:TEST001", "cancel:ZC001", "create:TEST001-R1"}; fmt.Sprint(calls()) != fmt.Sprint(want) {
+		t.Fatalf("接口调用 = %v, want %v", calls(), want)
+	}
+
+	// 确认平台已创建 ZC002
+	if err := tracker.ResolveRepost("ZC001", "ZC002"); err != nil {
+		t.Fatalf("ResolveRepost() error = %v", err)
+	}
+	old, _ := tracker.Get("ZC001")
+	created, _ := tracker.Get("ZC002")
+	if old.RepostedAs != "ZC002" || old.RepostPending != "" || created == nil || created.RepostOf != "ZC001" ||
+		created.SelfComment != "TEST001-R1" || created.Status != OrderStatusCreated {
+		t.Errorf("原订单 = %+v, 新订单 = %+v", old, created)
+	}
+	if _, err := tracker.Repost("ZC001", nil); !errors.Is(err, ErrOrderNotRepostable) {
+		t.Errorf("Repost() error = %v, want ErrOrderNotRepostable", err)
+	}
+	if err := tracker.ResolveRepost("ZC001", ""); err == nil {
+		t.Error("没有结果未知的重新发布时 ResolveRepost() 应返回错误")
+	}
+}
+
+func TestOrderTrackerResolveRepostNotCreated(t *testing.T) {
+	garbled := true
+	tracker, calls := newGarbledRepostTracker(t, &garbled)
+	tracker.Repost("ZC001", nil)
+
+	// 确认平台未创建后可以重新提交
+	garbled = false
+	if err := tracker.ResolveRepost("ZC001", ""); err != nil {
+		t.Fatalf("ResolveRepost() error = %v", err)
+	}
+	result, err := tracker.Repost("ZC001", nil)
+	if err != nil || result.OrderID != "ZC003" {
+		t.Fatalf("Repost() = %+v, %v", result, err)
+	}
+	if want := []string{"create:TEST001", "cancel:ZC001", "create:TEST001-R1", "create:TEST001-R1"}; fmt.Sprint(calls()) != fmt.Sprint(want) {
+		t.Errorf("接口调用 = %v, want %v", calls(), want)
+	}
+}
+
+func TestOrderTrackerRepostWithCreator(t *testing.T) {
+	garbled := true
+	tracker, calls := newGarbledRepostTracker(t, &garbled)
+	var lookups []string
+	creator, err := NewIdempotentOrderCreator(tracker.client, NewMemoryOrderLedger(), func(selfComment string) (string, bool, error) {
+		lookups = append(lookups, selfComment)
+		return "ZC002", true, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker.SetCreator(creator)
+
+	if _, err := tracker.Repost("ZC001", nil); !errors.Is(err, ErrOrderOutcomeUnknown) {
+		t.Fatalf("Repost() error = %v, want ErrOrderOutcomeUnknown", err)
+	}
+	// 对账查到平台已创建，不再提交
+	garbled = false
+	result, err := tracker.Repost("ZC001", nil)
+	if err != nil || result.OrderID != "ZC002" {
+		t.Fatalf("Repost() = %+v, %v", result, err)
+	}
+	if want := []string{"create:TEST001", "cancel:ZC001", "create:TEST001-R1"}; fmt.Sprint(calls()) != fmt.Sprint(want) {
+		t.Errorf("接口调用 = %v, want %v", calls(), want)
+	}
+	if fmt.Sprint(lookups) != "[TEST001-R1]" {
+		t.Errorf("对账 = %v", lookups)
+	}
+	if old, _ := tracker.Get("ZC001"); old.RepostedAs != "ZC002" || old.RepostPending != "" {
+		t.Errorf("原订单记录 = %+v", old)
+	}
+}
@@ -0,0 +1,252 @@
+package zczy
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ConsignorState 承运状态
+type ConsignorState string
+
+const (
+	// ConsignorStateDelisted 摘单
+	ConsignorStateDelisted ConsignorState = "5"
+	// ConsignorStateShipped 确认发货
+	ConsignorStateShipped ConsignorState = "6"
+	// ConsignorStateDelivered 确认收货
+	ConsignorStateDelivered ConsignorState = "7"
+	// ConsignorStateTerminated 已终止
+	ConsignorStateTerminated ConsignorState = "8"
+)
+
+var (
+	// ErrInvalidStateTransition 不允许的承运状态变更
+	ErrInvalidStateTransition = errors.New("不允许的承运状态变更")
+	// ErrOutOfOrderCallback 回调乱序到达（较早的状态晚于较新的状态到达）
+	ErrOutOfOrderCallback = errors.New("回调乱序到达")
+)
+
+// Valid 判断是否为已知的承运状态
+func (s ConsignorState) Valid() bool {
+	switch s {
+	case ConsignorStateDelisted, ConsignorStateShipped, ConsignorStateDelivered, ConsignorStateTerminated:
+		return true
+	}
+	return false
+}
+
+// Description 返回承运状态描述
+func (s ConsignorState) Description() string {
+	switch s {
+	case ConsignorStateDelisted:
+		return "摘单"
+	case ConsignorStateShipped:
+		return "确认发货"
+	case ConsignorStateDelivered:
+		return "确认收货"
+	case ConsignorStateTerminated:
+		return "已终止"
+	default:
+		return "未知"
+	}
+}
+
+// IsTerminal 判断是否为终态（确认收货或已终止）
+func (s ConsignorState) IsTerminal() bool {
+	return s == ConsignorStateDelivered || s == ConsignorStateTerminated
+}
+
+// rank 返回非终止状态在运输流程中的顺序，未知状态返回0
+func (s ConsignorState) rank() int {
+	switch s {
+	case ConsignorStateDelisted:
+		return 1
+	case ConsignorStateShipped:
+		return 2
+	case ConsignorStateDelivered:
+		return 3
+	}
+	return 0
+}
+
+// OrderEventType 订单状态事件类型
+type OrderEventType string
+
+const (
+	// OrderEventDelisted 已摘单
+	OrderEventDelisted OrderEventType = "delisted"
+	// OrderEventShipped 已发货
+	OrderEventShipped OrderEventType = "shipped"
+	// OrderEventDelivered 已收货
+	OrderEventDelivered OrderEventType = "delivered"
+	// OrderEventTerminated 已终止
+	OrderEventTerminated OrderEventType = "terminated"
+)
+
+// eventTypeOf 返回进入某承运状态时触发的事件类型
+func eventTypeOf(state ConsignorState) OrderEventType {
+	switch state {
+	case ConsignorStateDelisted:
+		return OrderEventDelisted
+	case ConsignorStateShipped:
+		return OrderEventShipped
+	case ConsignorStateDelivered:
+		return OrderEventDelivered
+	default:
+		return OrderEventTerminated
+	}
+}
+
+// OrderStateEvent 订单状态变更事件
+type OrderStateEvent struct {
+	Type         OrderEventType      // 事件类型
+	OrderID      string              // 订单号
+	From         ConsignorState      // 变更前状态，首次收到回调时为空
+	To           ConsignorState      // 变更后状态
+	Skipped      []ConsignorState    // 被跳过的中间状态（例如未收到确认发货就收到了确认收货）
+	Notification *DelistNotification // 触发变更的摘单通知
+}
+
+// OutOfOrder 判断本次变更是否跳过了中间状态
+func (e *OrderStateEvent) OutOfOrder() bool {
+	return len(e.Skipped) > 0
+}
+
+// OrderStateHandler 订单状态事件处理函数
+type OrderStateHandler func(event *OrderStateEvent)
+
+// StateTransitionError 承运状态变更错误
+type StateTransitionError struct {
+	OrderID string
+	From    ConsignorState
+	To      ConsignorState
+	Err     error // ErrInvalidStateTransition 或 ErrOutOfOrderCallback
+}
+
+func (e *StateTransitionError) Error() string {
+	return fmt.Sprintf("%v: orderId=%s, %s(%s) -> %s(%s)",
+		e.Err, e.OrderID, e.From, e.From.Description(), e.To, e.To.Description())
+}
+
+func (e *StateTransitionError) Unwrap() error {
+	return e.Err
+}
+
+// OrderStateMachine 根据摘单通知中的承运状态驱动的订单状态机
+// 状态流转：5-摘单 -> 6-确认发货 -> 7-确认收货，摘单或发货后可变为8-已终止
+type OrderStateMachine struct {
+	mu       sync.Mutex
+	states   map[string]ConsignorState
+	handlers map[OrderEventType][]OrderStateHandler
+	any      []OrderStateHandler
+}
+
+// NewOrderStateMachine 创建订单状态机
+func NewOrderStateMachine() *OrderStateMachine {
+	return &OrderStateMachine{
+		states:   make(map[string]ConsignorState),
+		handlers: make(map[OrderEventType][]OrderStateHandler),
+	}
+}
+
+// On 注册指定类型事件的处理函数
+func (m *OrderStateMachine) On(eventType OrderEventType, handler OrderStateHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[eventType] = append(m.handlers[eventType], handler)
+}
+
+// OnAny 注册所有事件的处理函数
+func (m *OrderStateMachine) OnAny(handler OrderStateHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.any = append(m.any, handler)
+}
+
+// State 返回订单当前的承运状态
+func (m *OrderStateMachine) State(orderID string) (ConsignorState, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.states[orderID]
+	return state, ok
+}
+
+// SetState 直接设置订单状态（用于从持久化存储恢复），不触发事件
+func (m *OrderStateMachine) SetState(orderID string, state ConsignorState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[orderID] = state
+}
+
+// Apply 应用摘单通知中的承运状态
+// 重复的状态返回(nil, nil)；跳过中间状态时仍会变更，事件的Skipped记录被跳过的状态；
+// 较早的状态晚到时返回ErrOutOfOrderCallback，不允许的变更返回ErrInvalidStateTransition
+func (m *OrderStateMachine) Apply(n *DelistNotification) (*OrderStateEvent, error) {
+	m.mu.Lock()
+	from := m.states[n.OrderID]
+	to := n.ConsignorState
+
+	skipped, err := checkTransition(from, to)
+	if err != nil {
+		m.mu.Unlock()
+		if errors.Is(err, errDuplicateState) {
+			return nil, nil
+		}
+		return nil, &StateTransitionError{OrderID: n.OrderID, From: from, To: to, Err: err}
+	}
+
+	m.states[n.OrderID] = to
+	event := &OrderStateEvent{
+		Type:         eventTypeOf(to),
+		OrderID:      n.OrderID,
+		From:         from,
+		To:           to,
+		Skipped:      skipped,
+		Notification: n,
+	}
+	handlers := append(append([]OrderStateHandler{}, m.handlers[event.Type]...), m.any...)
+	m.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+	return event, nil
+}
+
+// errDuplicateState 重复收到相同状态
+var errDuplicateState = errors.New("duplicate state")
+
+// checkTransition 校验状态变更，返回被跳过的中间状态
+func checkTransition(from, to ConsignorState) ([]ConsignorState, error) {
+	if !to.Valid() {
+		return nil, ErrInvalidStateTransition
+	}
+	if from == to {
+		return nil, errDuplicateState
+	}
+
+	switch {
+	case from == ConsignorStateTerminated:
+		// 终止后再收到运输中的状态，说明该回调晚于终止通知到达
+		return nil, ErrOutOfOrderCallback
+	case to == ConsignorStateTerminated:
+		if from == ConsignorStateDelivered {
+			return nil, ErrInvalidStateTransition
+		}
+		return nil, nil
+	}
+
+	fromRank, toRank := from.rank(), to.rank()
+	if toRank < fromRank {
+		return nil, ErrOutOfOrderCallback
+	}
+
+	var skipped []ConsignorState
+	for _, s := range []ConsignorState{ConsignorStateDelisted, ConsignorStateShipped} {
+		if s.rank() > fromRank && s.rank() < toRank {
+			skipped = append(skipped, s)
+		}
+	}
+	return skipped, nil
+}
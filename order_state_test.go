@@ -0,0 +1,117 @@
+package zczy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOrderStateMachine(t *testing.T) {
+	machine := NewOrderStateMachine()
+
+	var events []OrderEventType
+	machine.OnAny(func(event *OrderStateEvent) {
+		events = append(events, event.Type)
+	})
+	shipped := 0
+	machine.On(OrderEventShipped, func(event *OrderStateEvent) {
+		shipped++
+	})
+
+	for _, state := range []ConsignorState{ConsignorStateDelisted, ConsignorStateShipped, ConsignorStateDelivered} {
+		event, err := machine.Apply(&DelistNotification{OrderID: "A001", ConsignorState: state})
+		if err != nil {
+			t.Fatalf("Apply(%s) error = %v", state, err)
+		}
+		if event.OutOfOrder() {
+			t.Errorf("Apply(%s) 不应标记为乱序", state)
+		}
+	}
+
+	want := []OrderEventType{OrderEventDelisted, OrderEventShipped, OrderEventDelivered}
+	if len(events) != len(want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("events[%d] = %s, want %s", i, events[i], want[i])
+		}
+	}
+	if shipped != 1 {
+		t.Errorf("Shipped处理函数调用次数 = %d, want 1", shipped)
+	}
+
+	state, _ := machine.State("A001")
+	if state != ConsignorStateDelivered {
+		t.Errorf("State() = %s, want 7", state)
+	}
+}
+
+func TestOrderStateMachineOutOfOrder(t *testing.T) {
+	machine := NewOrderStateMachine()
+
+	if _, err := machine.Apply(&DelistNotification{OrderID: "A001", ConsignorState: ConsignorStateDelisted}); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	// 确认收货先于确认发货到达
+	event, err := machine.Apply(&DelistNotification{OrderID: "A001", ConsignorState: ConsignorStateDelivered})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !event.OutOfOrder() || event.Skipped[0] != ConsignorStateShipped {
+		t.Errorf("Skipped = %v, want [6]", event.Skipped)
+	}
+
+	// 迟到的确认发货
+	_, err = machine.Apply(&DelistNotification{OrderID: "A001", ConsignorState: ConsignorStateShipped})
+	if !errors.Is(err, ErrOutOfOrderCallback) {
+		t.Errorf("Apply() error = %v, want ErrOutOfOrderCallback", err)
+	}
+	var transitionErr *StateTransitionError
+	if !errors.As(err, &transitionErr) || transitionErr.From != ConsignorStateDelivered {
+		t.Errorf("应返回StateTransitionError，实际 %v", err)
+	}
+}
+
+func TestOrderStateMachineTransitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    ConsignorState
+		to      ConsignorState
+		wantErr error
+	}{
+		{"摘单后终止", ConsignorStateDelisted, ConsignorStateTerminated, nil},
+		{"发货后终止", ConsignorStateShipped, ConsignorStateTerminated, nil},
+		{"收货后终止", ConsignorStateDelivered, ConsignorStateTerminated, ErrInvalidStateTransition},
+		{"终止后发货", ConsignorStateTerminated, ConsignorStateShipped, ErrOutOfOrderCallback},
+		{"未知状态", ConsignorStateDelisted, "9", ErrInvalidStateTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machine := NewOrderStateMachine()
+			machine.SetState("A001", tt.from)
+
+			_, err := machine.Apply(&DelistNotification{OrderID: "A001", ConsignorState: tt.to})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Apply() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOrderStateMachineDuplicate(t *testing.T) {
+	machine := NewOrderStateMachine()
+	calls := 0
+	machine.OnAny(func(event *OrderStateEvent) { calls++ })
+
+	n := &DelistNotification{OrderID: "A001", ConsignorState: ConsignorStateDelisted}
+	machine.Apply(n)
+	event, err := machine.Apply(n)
+	if event != nil || err != nil {
+		t.Errorf("重复状态应返回(nil, nil)，实际(%v, %v)", event, err)
+	}
+	if calls != 1 {
+		t.Errorf("重复状态不应触发事件，调用次数 = %d", calls)
+	}
+}
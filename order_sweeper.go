@@ -0,0 +1,155 @@
+package zczy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SweepConfig 过期订单清理配置
+type SweepConfig struct {
+	Grace    time.Duration // 装货结束时间之后的宽限时间，超过后取消未摘单的订单
+	DryRun   bool          // 只报告过期订单，不取消
+	Interval time.Duration // Run 的检查间隔，默认10分钟
+	Batch    *BatchConfig  // 取消订单的并发与速率
+
+	// OnReport 在 Run 中每次清理后调用，没有过期订单且没有错误时不调用
+	OnReport func(report *SweepReport, err error)
+}
+
+// StaleOrder 装货结束时间已过仍未摘单的订单
+type StaleOrder struct {
+	OrderID     string       // 订单号
+	SelfComment string       // 自定义单号
+	DespatchEnd PlatformTime // 装货结束时间
+}
+
+// SweepReport 一次清理的结果
+type SweepReport struct {
+	At     time.Time    // 清理时间
+	DryRun bool         // 是否为演练
+	Orders []StaleOrder // 过期未摘单的订单，与 Cancel.Results 顺序一致
+	Cancel *BatchReport // 取消结果，演练时为nil
+}
+
+// Cancelled 返回已成功取消的订单号
+func (r *SweepReport) Cancelled() []string {
+	if r.Cancel == nil {
+		return nil
+	}
+	var ids []string
+	for _, result := range r.Cancel.Results {
+		if result.Status == BatchSucceeded {
+			ids = append(ids, result.OrderID)
+		}
+	}
+	return ids
+}
+
+// StaleOrderSweeper 取消装货结束时间已过仍未摘单的订单
+//
+// 只处理通过 OrderTracker 创建、记录中有创建请求且仍为已创建状态的订单，
+// 订单状态来自回调，需要将回调交给 tracker.HandleCallback
+type StaleOrderSweeper struct {
+	tracker *OrderTracker
+	config  SweepConfig
+	now     func() time.Time
+}
+
+// NewStaleOrderSweeper 创建过期订单清理器
+func NewStaleOrderSweeper(tracker *OrderTracker, config *SweepConfig) (*StaleOrderSweeper, error) {
+	if tracker == nil {
+		return nil, errors.New("tracker is required")
+	}
+	var cfg SweepConfig
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Grace < 0 {
+		return nil, errors.New("宽限时间不能为负")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Minute
+	}
+	return &StaleOrderSweeper{tracker: tracker, config: cfg, now: time.Now}, nil
+}
+
+// Stale 返回装货结束时间 + Grace 已过仍为已创建状态的订单
+func (s *StaleOrderSweeper) Stale() ([]StaleOrder, error) {
+	records, err := s.tracker.Find(OrderQuery{Status: OrderStatusCreated})
+	if err != nil {
+		return nil, fmt.Errorf("查询订单记录失败: %w", err)
+	}
+	deadline := s.now().Add(-s.config.Grace)
+	var stale []StaleOrder
+	for _, r := range records {
+		if r.Request == nil || !orderExpired(r.Request, deadline) {
+			continue
+		}
+		stale = append(stale, StaleOrder{
+			OrderID:     r.OrderID,
+			SelfComment: r.SelfComment,
+			DespatchEnd: r.Request.OrderInfo.DespatchEnd,
+		})
+	}
+	return stale, nil
+}
+
+// Sweep 执行一次清理
+// 取消前重新读取订单记录，期间已摘单或已取消的订单不再取消，结果为 BatchSkipped；
+// ctx 取消后不再发起新的取消请求，未发起的订单结果为 BatchSkipped，并返回 ctx.Err()
+func (s *StaleOrderSweeper) Sweep(ctx context.Context) (*SweepReport, error) {
+	report := &SweepReport{At: s.now(), DryRun: s.config.DryRun}
+	stale, err := s.Stale()
+	if err != nil {
+		return report, err
+	}
+	report.Orders = stale
+	if s.config.DryRun || len(stale) == 0 {
+		return report, nil
+	}
+
+	orderIDs := make([]string, len(stale))
+	for i, o := range stale {
+		orderIDs[i] = o.OrderID
+	}
+	report.Cancel, err = runBatch(ctx, orderIDs, s.config.Batch, func(i int) error {
+		// 与重新发布互斥，避免同一订单被重复取消
+		if !s.tracker.acquireRepost(orderIDs[i]) {
+			return fmt.Errorf("%w: %w: %s", errBatchSkip, ErrOrderInProgress, orderIDs[i])
+		}
+		defer s.tracker.releaseRepost(orderIDs[i])
+		// Stale 之后可能已收到摘单通知或被取消，重新读取状态
+		record, err := s.tracker.Get(orderIDs[i])
+		if err != nil {
+			return fmt.Errorf("读取订单记录失败: %w", err)
+		}
+		if record == nil || record.Status != OrderStatusCreated {
+			status := OrderStatus("")
+			if record != nil {
+				status = record.Status
+			}
+			return fmt.Errorf("%w: %s 当前状态为%s", errBatchSkip, orderIDs[i], status)
+		}
+		return s.tracker.CancelOrder(orderIDs[i])
+	})
+	return report, err
+}
+
+// Run 按 Interval 定期执行 Sweep，直到 ctx 取消
+func (s *StaleOrderSweeper) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		report, err := s.Sweep(ctx)
+		if s.config.OnReport != nil && (err != nil || len(report.Orders) > 0) {
+			s.config.OnReport(report, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package zczy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// newSweeperTestTracker 创建三个订单：ZC001 过期未摘单，ZC002 未过期，ZC003 过期但已摘单
+func newSweeperTestTracker(t *testing.T) (*OrderTracker, func() []string) {
+	tracker, calls := newRepostTestTracker(t)
+	future := newValidCreateOrderRequest()
+	future.OrderInfo.SelfComment = "TEST002"
+	future.OrderInfo.DespatchEnd = NewPlatformTime(time.Now().Add(time.Hour))
+	future.OrderInfo.ReceiveDate = NewPlatformTime(time.Now().Add(48 * time.Hour))
+	delisted := newValidCreateOrderRequest()
+	delisted.OrderInfo.SelfComment = "TEST003"
+
+	for _, req := range []*CreateOrderRequest{newValidCreateOrderRequest(), future, delisted} {
+		if _, err := tracker.CreateOrder(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tracker.Apply(&DelistNotification{OrderID: "ZC003", ConsignorState: ConsignorStateDelisted}); err != nil {
+		t.Fatal(err)
+	}
+	return tracker, calls
+}
+
+func TestStaleOrderSweeperSweep(t *testing.T) {
+	tracker, calls := newSweeperTestTracker(t)
+	sweeper, err := NewStaleOrderSweeper(tracker, &SweepConfig{Grace: 2 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := sweeper.Sweep(context.Background())
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if len(report.Orders) != 1 || report.Orders[0].OrderID != "ZC001" || report.Orders[0].DespatchEnd != "2025-01-20 12:00" {
+		t.Fatalf("Orders = %+v", report.Orders)
+	}
+	if got := report.Cancelled(); fmt.Sprint(got) != "[ZC001]" {
+		t.Errorf("Cancelled() = %v, want [ZC001]", got)
+	}
+	if record, _ := tracker.Get("ZC001"); record.Status != OrderStatusCancelled {
+		t.Errorf("ZC001 状态 = %s, want cancelled", record.Status)
+	}
+
+	// 已取消的订单不再处理
+	report, _ = sweeper.Sweep(context.Background())
+	if len(report.Orders) != 0 || report.Cancel != nil {
+		t.Errorf("再次 Sweep() = %+v", report)
+	}
+	want := []string{"create:TEST001", "create:TEST002", "create:TEST003", "cancel:ZC001"}
+	if got := calls(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("接口调用 = %v, want %v", got, want)
+	}
+}
+
+func TestStaleOrderSweeperGrace(t *testing.T) {
+	tracker, _ := newSweeperTestTracker(t)
+	sweeper, err := NewStaleOrderSweeper(tracker, &SweepConfig{Grace: 3 * time.Hour, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ZC001 装货结束时间 2025-01-20 12:00，宽限期内不处理
+	sweeper.now = func() time.Time { return time.Date(2025, 1, 20, 14, 59, 0, 0, ChinaLocation) }
+	if stale, _ := sweeper.Stale(); len(stale) != 0 {
+		t.Errorf("宽限期内 Stale() = %+v", stale)
+	}
+
+	sweeper.now = func() time.Time { return time.Date(2025, 1, 20, 15, 1, 0, 0, ChinaLocation) }
+	report, err := sweeper.Sweep(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 演练只报告，不取消
+	if !report.DryRun || len(report.Orders) != 1 || report.Cancel != nil || report.Cancelled() != nil {
+		t.Errorf("Sweep() = %+v", report)
+	}
+	if record, _ := tracker.Get("ZC001"); record.Status != OrderStatusCreated {
+		t.Errorf("演练后 ZC001 状态 = %s, want created", record.Status)
+	}
+}
+
+func TestStaleOrderSweeperRun(t *testing.T) {
+	tracker, _ := newSweeperTestTracker(t)
+	var reports []*SweepReport
+	sweeper, err := NewStaleOrderSweeper(tracker, &SweepConfig{
+		Interval: time.Millisecond,
+		OnReport: func(report *SweepReport, err error) { reports = append(reports, report) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := sweeper.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() error = %v, want DeadlineExceeded", err)
+	}
+	// 只有第一次清理发现过期订单
+	if len(reports) != 1 || fmt.Sprint(reports[0].Cancelled()) != "[ZC001]" {
+		t.Errorf("OnReport 调用 = %+v", reports)
+	}
+
+	// ctx 已取消时不发起取消请求
+	tracker, calls := newSweeperTestTracker(t)
+	sweeper, _ = NewStaleOrderSweeper(tracker, nil)
+	cancelled, stop := context.WithCancel(context.Background())
+	stop()
+	report, err := sweeper.Sweep(cancelled)
+	if !errors.Is(err, context.Canceled) || report.Cancel.Summary.Skipped != 1 {
+		t.Errorf("Sweep() = %+v, %v", report.Cancel, err)
+	}
+	if got := calls(); len(got) != 3 {
+		t.Errorf("接口调用 = %v", got)
+	}
+}
+
+// delistOnFindStore 在 Find 返回后将订单改为已摘单，模拟 Stale 与取消之间收到摘单通知
+type delistOnFindStore struct {
+	*MemoryOrderStore
+	orderID string
+}
+
+func (s *delistOnFindStore) Find(query OrderQuery) ([]*OrderRecord, error) {
+	records, err := s.MemoryOrderStore.Find(query)
+	if r, _ := s.Get(s.orderID); r != nil {
+		r.Status = OrderStatusDelisted
+		s.Put(r)
+	}
+	return records, err
+}
+
+func TestStaleOrderSweeperRecheck(t *testing.T) {
+	var cancelled []string
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		if method == MethodOrderCancel {
+			cancelled = append(cancelled, params)
+		}
+		return &Response{Code: "0000", Result: map[string]string{"orderId": "ZC001"}}
+	})
+	tracker, err := NewOrderTracker(client, &delistOnFindStore{MemoryOrderStore: NewMemoryOrderStore(), orderID: "ZC001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tracker.CreateOrder(newValidCreateOrderRequest()); err != nil {
+		t.Fatal(err)
+	}
+	sweeper, _ := NewStaleOrderSweeper(tracker, nil)
+
+	report, err := sweeper.Sweep(context.Background())
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if len(report.Orders) != 1 || report.Cancel.Results[0].Status != BatchSkipped || report.Cancelled() != nil {
+		t.Errorf("Sweep() = %+v", report.Cancel)
+	}
+	if len(cancelled) != 0 {
+		t.Errorf("已摘单的订单不应取消，实际取消请求 %v", cancelled)
+	}
+	if record, _ := tracker.Get("ZC001"); record.Status != OrderStatusDelisted {
+		t.Errorf("ZC001 状态 = %s, want delisted", record.Status)
+	}
+}
+
+func TestStaleOrderSweeperRepostInProgress(t *testing.T) {
+	tracker, calls := newSweeperTestTracker(t)
+	sweeper, _ := NewStaleOrderSweeper(tracker, nil)
+
+	// 模拟 ZC001 正在重新发布
+	if !tracker.acquireRepost("ZC001") {
+		t.Fatal("acquireRepost() = false")
+	}
+	report, err := sweeper.Sweep(context.Background())
+	tracker.releaseRepost("ZC001")
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if len(report.Orders) != 1 || report.Cancel.Results[0].Status != BatchSkipped {
+		t.Fatalf("Sweep() = %+v", report.Cancel)
+	}
+	if err := report.Cancel.Results[0].Err; !errors.Is(err, ErrOrderInProgress) {
+		t.Errorf("Err = %v, want ErrOrderInProgress", err)
+	}
+	for _, call := range calls() {
+		if call == "cancel:ZC001" {
+			t.Errorf("重新发布中的订单不应取消，接口调用 = %v", calls())
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package zczy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrTemplateParamMissing 渲染模板时缺少占位符对应的参数
+var ErrTemplateParamMissing = errors.New("缺少模板参数")
+
+// templatePlaceholder 匹配模板中的 ${name} 占位符
+var templatePlaceholder = regexp.MustCompile(`\$\{([A-Za-z0-9_.\-]+)\}`)
+
+// OrderTemplate 订单模板
+// 模板文件与创建订单请求的JSON结构相同（orderInfo、cargoList、orderAddressInfo、orderReceiptInfo），
+// 字符串字段中可以使用 ${name} 占位符，渲染时替换为参数值，例如：
+//
+//	{
+//	  "orderInfo": {"orderModel": "抢单", "totalAmount": "${totalAmount}", "despatchStart": "${despatchStart}"},
+//	  "cargoList": [{"cargoName": "钢材", "weight": "${weight}"}]
+//	}
+type OrderTemplate struct {
+	Name         string   // 模板名称，从文件加载时为不含扩展名的文件名
+	data         any      // 解析后的模板JSON
+	placeholders []string // 模板中出现的占位符名称（已排序、去重）
+}
+
+// ParseOrderTemplate 解析订单模板，同时检查字段名是否与创建订单请求一致
+func ParseOrderTemplate(name string, data []byte) (*OrderTemplate, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var raw any
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("解析模板%s失败: %v", name, err)
+	}
+	if _, ok := raw.(map[string]any); !ok {
+		return nil, fmt.Errorf("模板%s必须为JSON对象", name)
+	}
+
+	t := &OrderTemplate{Name: name, data: raw}
+	seen := make(map[string]bool)
+	walkTemplateStrings(raw, func(s string) string {
+		for _, m := range templatePlaceholder.FindAllStringSubmatch(s, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				t.placeholders = append(t.placeholders, m[1])
+			}
+		}
+		return s
+	})
+	sort.Strings(t.placeholders)
+
+	// 占位符全部替换为空值后按严格模式解析，提前发现拼写错误的字段名
+	empty := make(map[string]string, len(t.placeholders))
+	for _, p := range t.placeholders {
+		empty[p] = ""
+	}
+	if _, err := t.decode(empty, true); err != nil {
+		return nil, fmt.Errorf("模板%s字段错误: %v", name, err)
+	}
+	return t, nil
+}
+
+// LoadOrderTemplate 从文件加载订单模板，模板名称为不含扩展名的文件名
+func LoadOrderTemplate(path string) (*OrderTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取模板文件失败: %v", err)
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return ParseOrderTemplate(name, data)
+}
+
+// LoadOrderTemplates 加载目录下所有 .json 模板文件，按模板名称索引
+func LoadOrderTemplates(dir string) (map[string]*OrderTemplate, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]*OrderTemplate, len(paths))
+	for _, path := range paths {
+		t, err := LoadOrderTemplate(path)
+		if err != nil {
+			return nil, err
+		}
+		templates[t.Name] = t
+	}
+	return templates, nil
+}
+
+// Placeholders 返回模板中的占位符名称（已排序）
+func (t *OrderTemplate) Placeholders() []string {
+	return append([]string(nil), t.placeholders...)
+}
+
+// Render 使用参数替换占位符生成创建订单请求，再将 overrides 中的非空字段逐个覆盖到模板上
+// overrides 的货物列表按下标与模板货物合并，超出模板数量的货物直接追加
+// 生成的请求通过构建器的 BuildValidated 校验，校验失败时同时返回请求和 ValidationErrors
+func (t *OrderTemplate) Render(params map[string]string, overrides *CreateOrderRequest) (*CreateOrderRequest, error) {
+	var missing []string
+	for _, p := range t.placeholders {
+		if _, ok := params[p]; !ok {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: 模板%s需要 %s", ErrTemplateParamMissing, t.Name, strings.Join(missing, ", "))
+	}
+
+	req, err := t.decode(params, false)
+	if err != nil {
+		return nil, fmt.Errorf("渲染模板%s失败: %v", t.Name, err)
+	}
+	if overrides != nil {
+		mergeOrderRequest(req, overrides)
+	}
+
+	return NewCreateOrderRequestBuilder().
+		SetOrderInfo(req.OrderInfo).
+		SetCargoList(req.CargoList).
+		SetOrderAddressInfo(req.OrderAddressInfo).
+		SetOrderReceiptInfo(req.OrderReceiptInfo).
+		BuildValidated()
+}
+
+// decode 替换占位符后解析为创建订单请求
+func (t *OrderTemplate) decode(params map[string]string, strict bool) (*CreateOrderRequest, error) {
+	rendered := walkTemplateStrings(deepCopyJSON(t.data), func(s string) string {
+		return templatePlaceholder.ReplaceAllStringFunc(s, func(m string) string {
+			return params[m[2:len(m)-1]]
+		})
+	})
+
+	data, err := json.Marshal(rendered)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	var req CreateOrderRequest
+	if err := decoder.Decode(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// CreateOrderFromTemplate 渲染模板并创建订单，校验不通过时不会调用平台接口
+func (c *Client) CreateOrderFromTemplate(t *OrderTemplate, params map[string]string, overrides *CreateOrderRequest) (*CreateOrderResponse, error) {
+	req, err := t.Render(params, overrides)
+	if err != nil {
+		return nil, err
+	}
+	return c.CreateOrder(req)
+}
+
+// walkTemplateStrings 遍历JSON值中的所有字符串并替换，返回替换后的值
+func walkTemplateStrings(v any, fn func(string) string) any {
+	switch x := v.(type) {
+	case string:
+		return fn(x)
+	case map[string]any:
+		for k, item := range x {
+			x[k] = walkTemplateStrings(item, fn)
+		}
+	case []any:
+		for i, item := range x {
+			x[i] = walkTemplateStrings(item, fn)
+		}
+	}
+	return v
+}
+
+// deepCopyJSON 复制解析后的JSON值，避免渲染时修改模板
+func deepCopyJSON(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(x))
+		for k, item := range x {
+			m[k] = deepCopyJSON(item)
+		}
+		return m
+	case []any:
+		s := make([]any, len(x))
+		for i, item := range x {
+			s[i] = deepCopyJSON(item)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// mergeOrderRequest 将 src 中的非空字段覆盖到 dst
+func mergeOrderRequest(dst, src *CreateOrderRequest) {
+	mergeFields(&dst.OrderInfo, &src.OrderInfo)
+	mergeFields(&dst.OrderAddressInfo, &src.OrderAddressInfo)
+	mergeFields(&dst.OrderReceiptInfo, &src.OrderReceiptInfo)
+
+	for i := range src.CargoList {
+		if i < len(dst.CargoList) {
+			mergeFields(&dst.CargoList[i], &src.CargoList[i])
+		} else {
+			dst.CargoList = append(dst.CargoList, src.CargoList[i])
+		}
+	}
+}
+
+// mergeFields 逐个字段将 src 中的非零值复制到 dst，dst 和 src 必须是指向同类型结构体的指针
+func mergeFields(dst, src any) {
+	d := reflect.ValueOf(dst).Elem()
+	s := reflect.ValueOf(src).Elem()
+	for i := 0; i < s.NumField(); i++ {
+		if field := s.Field(i); !field.IsZero() {
+			d.Field(i).Set(field)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package zczy
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestOrderTemplateJSON 由可以通过校验的请求生成模板，重量和装货时间使用占位符
+func newTestOrderTemplateJSON(t *testing.T) []byte {
+	t.Helper()
+	data, err := json.Marshal(newValidCreateOrderRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raw map[string]any
+	json.Unmarshal(data, &raw)
+	orderInfo := raw["orderInfo"].(map[string]any)
+	orderInfo["despatchStart"] = "${date} 08:00"
+	orderInfo["despatchEnd"] = "${date} 12:00"
+	orderInfo["selfComment"] = "TPL-${batch}"
+	raw["cargoList"].([]any)[0].(map[string]any)["weight"] = "${weight}"
+
+	data, _ = json.Marshal(raw)
+	return data
+}
+
+func TestOrderTemplateRender(t *testing.T) {
+	tpl, err := ParseOrderTemplate("steel", newTestOrderTemplateJSON(t))
+	if err != nil {
+		t.Fatalf("ParseOrderTemplate() error = %v", err)
+	}
+	if got := tpl.Placeholders(); len(got) != 3 || got[0] != "batch" || got[1] != "date" || got[2] != "weight" {
+		t.Errorf("Placeholders() = %v", got)
+	}
+
+	params := map[string]string{"date": "2025-01-20", "weight": "28.5", "batch": "B01"}
+	overrides := &CreateOrderRequest{
+		OrderInfo: OrderInfo{TotalAmount: "4800"},
+		CargoList: []CargoInfo{{}, {CargoName: "铝材", CargoCategory: CargoCategoryHeavy, Weight: "2", Pack: "箱"}},
+	}
+	req, err := tpl.Render(params, overrides)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	if req.OrderInfo.DespatchStart != "2025-01-20 08:00" || req.OrderInfo.SelfComment != "TPL-B01" {
+		t.Errorf("占位符替换错误: %s / %s", req.OrderInfo.DespatchStart, req.OrderInfo.SelfComment)
+	}
+	if req.OrderInfo.TotalAmount != "4800" || req.OrderInfo.ContactName != "赵先生" {
+		t.Errorf("字段覆盖错误: totalAmount=%s contactName=%s", req.OrderInfo.TotalAmount, req.OrderInfo.ContactName)
+	}
+	if len(req.CargoList) != 2 || req.CargoList[0].Weight != "28.5" || req.CargoList[0].CargoName != "钢材" {
+		t.Errorf("货物合并错误: %+v", req.CargoList)
+	}
+
+	// 渲染不应修改模板
+	again, err := tpl.Render(map[string]string{"date": "2025-01-22", "weight": "10", "batch": "B02"}, nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if again.OrderInfo.TotalAmount != "5000.00" || len(again.CargoList) != 1 {
+		t.Errorf("第二次渲染受到覆盖值影响: %+v", again.OrderInfo)
+	}
+}
+
+func TestOrderTemplateErrors(t *testing.T) {
+	if _, err := ParseOrderTemplate("bad", []byte(`{"orderInfo": {"orderModle": "抢单"}}`)); err == nil {
+		t.Error("拼写错误的字段名应返回错误")
+	}
+
+	tpl, err := ParseOrderTemplate("steel", newTestOrderTemplateJSON(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tpl.Render(map[string]string{"date": "2025-01-20"}, nil); !errors.Is(err, ErrTemplateParamMissing) {
+		t.Errorf("Render() error = %v, want ErrTemplateParamMissing", err)
+	}
+
+	_, err = tpl.Render(map[string]string{"date": "2025-01-20", "weight": "", "batch": "B01"}, nil)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || errs.Field("cargoList[0].weight") == nil {
+		t.Errorf("Render() error = %v, want cargoList[0].weight", err)
+	}
+}
+
+func TestLoadOrderTemplates(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "steel.json"), newTestOrderTemplateJSON(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := LoadOrderTemplates(dir)
+	if err != nil {
+		t.Fatalf("LoadOrderTemplates() error = %v", err)
+	}
+	if tpl := templates["steel"]; tpl == nil || tpl.Name != "steel" {
+		t.Errorf("LoadOrderTemplates() = %v", templates)
+	}
+}
@@ -0,0 +1,480 @@
+package zczy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OrderStatus 本地跟踪的订单生命周期状态
+type OrderStatus string
+
+const (
+	// OrderStatusCreated 已创建，等待摘单
+	OrderStatusCreated OrderStatus = "created"
+	// OrderStatusCancelled 已取消
+	OrderStatusCancelled OrderStatus = "cancelled"
+	// OrderStatusDelisted 已摘单
+	OrderStatusDelisted OrderStatus = "delisted"
+	// OrderStatusShipped 已确认发货
+	OrderStatusShipped OrderStatus = "shipped"
+	// OrderStatusDelivered 已确认收货
+	OrderStatusDelivered OrderStatus = "delivered"
+	// OrderStatusReceiptConfirmed 已回单确认
+	OrderStatusReceiptConfirmed OrderStatus = "receipt_confirmed"
+	// OrderStatusTerminated 已终止
+	OrderStatusTerminated OrderStatus = "terminated"
+)
+
+// orderStatusOf 返回承运状态对应的生命周期状态
+func orderStatusOf(state ConsignorState) OrderStatus {
+	switch state {
+	case ConsignorStateDelisted:
+		return OrderStatusDelisted
+	case ConsignorStateShipped:
+		return OrderStatusShipped
+	case ConsignorStateDelivered:
+		return OrderStatusDelivered
+	default:
+		return OrderStatusTerminated
+	}
+}
+
+// OrderHistoryEntry 订单状态变更记录
+type OrderHistoryEntry struct {
+	At     time.Time   `json:"at"`             // 变更时间
+	Status OrderStatus `json:"status"`         // 变更后状态
+	Source string      `json:"source"`         // 来源：api-SDK调用，callback-平台回调
+	Note   string      `json:"note,omitempty"` // 说明
+}
+
+// OrderRecord 本地订单记录
+type OrderRecord struct {
+	OrderID        string                     `json:"orderId"`                  // 平台订单号
+	SelfComment    string                     `json:"selfComment,omitempty"`    // 自定义单号
+	Request        *CreateOrderRequest        `json:"request,omitempty"`        // 创建订单请求（通过跟踪器创建时记录）
+	Status         OrderStatus                `json:"status"`                   // 当前状态
+	ConsignorState ConsignorState             `json:"consignorState,omitempty"` // 最近一次回调的承运状态
+	CarrierName    string                     `json:"carrierName,omitempty"`    // 承运方姓名
+	CarrierMobile  string                     `json:"carrierMobile,omitempty"`  // 承运方手机号
+	DriverName     string                     `json:"driverName,omitempty"`     // 司机姓名
+	DriverMobile   string                     `json:"driverMobile,omitempty"`   // 司机手机号
+	PlateNumber    string                     `json:"plateNumber,omitempty"`    // 车牌号
+	RepostOf       string                     `json:"repostOf,omitempty"`       // 由该订单重新发布而来
+	RepostedAs     string                     `json:"repostedAs,omitempty"`     // 已重新发布为该订单
+	RepostPending  string                     `json:"repostPending,omitempty"`  // 正在重新发布的新自定义单号，关联完成后清空
+	Breaches       []BreachResultNotification `json:"breaches,omitempty"`       // 违约处理结果
+	History        []OrderHistoryEntry        `json:"history"`                  // 状态变更历史
+	CreatedAt      time.Time                  `json:"createdAt"`                // 记录创建时间
+	UpdatedAt      time.Time                  `json:"updatedAt"`                // 记录更新时间
+}
+
+// clone 深拷贝订单记录，避免存储与调用方共享切片
+func (r *OrderRecord) clone() *OrderRecord {
+	c := *r
+	if r.Request != nil {
+		c.Request = r.Request.clone()
+	}
+	c.Breaches = append([]BreachResultNotification(nil), r.Breaches...)
+	c.History = append([]OrderHistoryEntry(nil), r.History...)
+	return &c
+}
+
+// transition 变更状态并记录历史
+func (r *OrderRecord) transition(status OrderStatus, source, note string, at time.Time) {
+	r.Status = status
+	r.History = append(r.History, OrderHistoryEntry{At: at, Status: status, Source: source, Note: note})
+	r.UpdatedAt = at
+}
+
+// OrderQuery 订单查询条件，零值字段表示不限制
+type OrderQuery struct {
+	Status      OrderStatus // 当前状态
+	SelfComment string      // 自定义单号
+	From        time.Time   // 记录创建时间下限（含）
+	To          time.Time   // 记录创建时间上限（不含）
+}
+
+// Match 判断记录是否符合查询条件，供自定义 OrderStore 实现使用
+func (q *OrderQuery) Match(r *OrderRecord) bool {
+	if q.Status != "" && r.Status != q.Status {
+		return false
+	}
+	if q.SelfComment != "" && r.SelfComment != q.SelfComment {
+		return false
+	}
+	if !q.From.IsZero() && r.CreatedAt.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !r.CreatedAt.Before(q.To) {
+		return false
+	}
+	return true
+}
+
+// OrderStore 订单记录存储，可以使用数据库等自行实现，实现需要保证并发安全
+type OrderStore interface {
+	// Get 按订单号查询，不存在时返回 nil, nil
+	Get(orderID string) (*OrderRecord, error)
+	// Put 新增或覆盖记录
+	Put(record *OrderRecord) error
+	// Find 按创建时间顺序返回符合条件的记录
+	Find(query OrderQuery) ([]*OrderRecord, error)
+}
+
+// MemoryOrderStore 内存订单存储，进程重启后丢失
+type MemoryOrderStore struct {
+	mu      sync.Mutex
+	records map[string]*OrderRecord
+}
+
+// NewMemoryOrderStore 创建内存订单存储
+func NewMemoryOrderStore() *MemoryOrderStore {
+	return &MemoryOrderStore{records: make(map[string]*OrderRecord)}
+}
+
+func (s *MemoryOrderStore) Get(orderID string) (*OrderRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.records[orderID]; ok {
+		return r.clone(), nil
+	}
+	return nil, nil
+}
+
+func (s *MemoryOrderStore) Put(record *OrderRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.OrderID] = record.clone()
+	return nil
+}
+
+func (s *MemoryOrderStore) Find(query OrderQuery) ([]*OrderRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []*OrderRecord
+	for _, r := range s.records {
+		if query.Match(r) {
+			result = append(result, r.clone())
+		}
+	}
+	sortOrderRecords(result)
+	return result, nil
+}
+
+// FileOrderStore 文件订单存储，每个订单保存为目录下的一个JSON文件
+type FileOrderStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileOrderStore 创建文件订单存储，目录不存在时自动创建
+func NewFileOrderStore(dir string) (*FileOrderStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create store dir error: %w", err)
+	}
+	return &FileOrderStore{dir: dir}, nil
+}
+
+func (s *FileOrderStore) path(orderID string) string {
+	return filepath.Join(s.dir, url.PathEscape(orderID)+".json")
+}
+
+func (s *FileOrderStore) Get(orderID string) (*OrderRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(s.path(orderID))
+}
+
+// Put 先写临时文件再重命名，保证文件完整
+func (s *FileOrderStore) Put(record *OrderRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal order record error: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.path(record.OrderID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("persist order record error: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("persist order record error: %w", err)
+	}
+	return nil
+}
+
+func (s *FileOrderStore) Find(query OrderQuery) ([]*OrderRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var result []*OrderRecord
+	for _, path := range paths {
+		r, err := s.read(path)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil && query.Match(r) {
+			result = append(result, r)
+		}
+	}
+	sortOrderRecords(result)
+	return result, nil
+}
+
+// read 读取单个订单文件，文件不存在时返回nil
+func (s *FileOrderStore) read(path string) (*OrderRecord, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read order record error: %w", err)
+	}
+	var r OrderRecord
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("解析订单记录%s失败: %v", filepath.Base(path), err)
+	}
+	return &r, nil
+}
+
+// sortOrderRecords 按创建时间排序，相同时按订单号排序
+func sortOrderRecords(records []*OrderRecord) {
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].CreatedAt.Equal(records[j].CreatedAt) {
+			return records[i].CreatedAt.Before(records[j].CreatedAt)
+		}
+		return records[i].OrderID < records[j].OrderID
+	})
+}
+
+// OrderTracker 本地订单生命周期跟踪
+// 通过跟踪器调用 CreateOrder、CancelOrder、ConfirmReceipt，并将回调交给 HandleCallback，
+// 即可在 OrderStore 中维护每个订单的承运信息和状态历史
+type OrderTracker struct {
+	client    *Client
+	store     OrderStore
+	creator   *IdempotentOrderCreator // 不为nil时通过台账防重创建
+	mu        sync.Mutex
+	reposting map[string]bool // 正在重新发布的订单号
+}
+
+// NewOrderTracker 创建订单跟踪器
+func NewOrderTracker(client *Client, store OrderStore) (*OrderTracker, error) {
+	if client == nil {
+		return nil, errors.New("client is required")
+	}
+	if store == nil {
+		return nil, errors.New("store is required")
+	}
+	return &OrderTracker{client: client, store: store, reposting: make(map[string]bool)}, nil
+}
+
+// SetCreator 设置防重订单创建器，之后 CreateOrder 和 Repost 通过 creator 的台账提交，
+// 结果未知的自定义单号先对账再决定是否重新提交。需在开始使用跟踪器前调用，creator 应使用同一个 Client
+func (t *OrderTracker) SetCreator(creator *IdempotentOrderCreator) {
+	t.creator = creator
+}
+
+// CreateOrder 创建订单并记录请求，回调先于创建结果到达时合并到已有记录
+func (t *OrderTracker) CreateOrder(req *CreateOrderRequest) (*CreateOrderResponse, error) {
+	create := t.client.CreateOrder
+	if t.creator != nil {
+		create = t.creator.Create
+	}
+	resp, err := create(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.update(resp.OrderID, req.OrderInfo.SelfComment, func(r *OrderRecord, now time.Time) error {
+		r.Request = req
+		if r.SelfComment == "" {
+			r.SelfComment = req.OrderInfo.SelfComment
+		}
+		if r.Status == "" {
+			r.transition(OrderStatusCreated, "api", "", now)
+			return nil
+		}
+		// 回调早于创建结果到达，保留回调更新的状态和承运信息
+		r.History = append(r.History, OrderHistoryEntry{At: now, Status: r.Status, Source: "api", Note: "创建结果晚于回调到达"})
+		r.UpdatedAt = now
+		return nil
+	})
+	return resp, err
+}
+
+// CancelOrder 取消订单并记录状态
+func (t *OrderTracker) CancelOrder(orderID string) error {
+	if err := t.client.CancelOrder(orderID); err != nil {
+		return err
+	}
+	return t.update(orderID, "", func(r *OrderRecord, now time.Time) error {
+		r.transition(OrderStatusCancelled, "api", "", now)
+		return nil
+	})
+}
+
+// ConfirmReceipt 回单确认并记录状态
+func (t *OrderTracker) ConfirmReceipt(req *ConfirmReceiptRequest) error {
+	if err := t.client.ConfirmReceipt(req); err != nil {
+		return err
+	}
+	return t.update(req.OrderID, "", func(r *OrderRecord, now time.Time) error {
+		r.transition(OrderStatusReceiptConfirmed, "api", fmt.Sprintf("收货吨位%s", req.Tonnage), now)
+		return nil
+	})
+}
+
+// HandleCallback 验签并识别通知类型后更新订单记录，可作为CallbackHandler使用
+func (t *OrderTracker) HandleCallback(req *CallbackRequest) error {
+	event, err := t.client.DecodeCallback(req)
+	if err != nil {
+		return err
+	}
+	return t.Apply(event)
+}
+
+// Apply 使用已解析的回调通知更新订单记录，未通过跟踪器创建的订单会自动建立记录
+// 重复的承运状态被忽略；乱序到达的状态只记录到历史中，不会回退当前状态；
+// 本地已取消或已回单确认的订单收到摘单通知时保留当前状态，冲突记录在历史中
+func (t *OrderTracker) Apply(event Event) error {
+	switch n := event.(type) {
+	case *DelistNotification:
+		return t.update(n.OrderID, n.SelfComment, func(r *OrderRecord, now time.Time) error {
+			return r.applyDelist(n, now)
+		})
+	case *BreachResultNotification:
+		return t.update(n.OrderID, "", func(r *OrderRecord, now time.Time) error {
+			r.Breaches = append(r.Breaches, *n)
+			if n.IsStop == "1" {
+				r.ConsignorState = ConsignorStateTerminated
+				r.transition(OrderStatusTerminated, "callback", "违约处理终止运单", now)
+			} else {
+				r.UpdatedAt = now
+			}
+			return nil
+		})
+	default:
+		return nil
+	}
+}
+
+// applyDelist 应用摘单通知中的承运信息和状态
+func (r *OrderRecord) applyDelist(n *DelistNotification, now time.Time) error {
+	if n.SelfComment != "" {
+		r.SelfComment = n.SelfComment
+	}
+	for dst, src := range map[*string]string{
+		&r.CarrierName:   n.CarrierName,
+		&r.CarrierMobile: n.CarrierMobile,
+		&r.DriverName:    n.DriverUserName,
+		&r.DriverMobile:  n.DriverMobile,
+		&r.PlateNumber:   n.PlateNumber,
+	} {
+		if src != "" {
+			*dst = src
+		}
+	}
+
+	skipped, err := checkTransition(r.ConsignorState, n.ConsignorState)
+	switch {
+	case errors.Is(err, errDuplicateState):
+		r.UpdatedAt = now
+		return nil
+	case errors.Is(err, ErrOutOfOrderCallback):
+		r.History = append(r.History, OrderHistoryEntry{
+			At:     now,
+			Status: orderStatusOf(n.ConsignorState),
+			Source: "callback",
+			Note:   "乱序到达，未变更当前状态",
+		})
+		r.UpdatedAt = now
+		return nil
+	case err != nil:
+		return &StateTransitionError{OrderID: n.OrderID, From: r.ConsignorState, To: n.ConsignorState, Err: err}
+	}
+
+	// 本地已取消或已回单确认的订单不被回调覆盖，只记录平台状态及冲突
+	if r.Status == OrderStatusCancelled || r.Status == OrderStatusReceiptConfirmed {
+		r.ConsignorState = n.ConsignorState
+		note := "已回单确认，未变更当前状态"
+		if r.Status == OrderStatusCancelled {
+			note = "状态冲突：本地已取消，未变更当前状态"
+		}
+		r.History = append(r.History, OrderHistoryEntry{
+			At:     now,
+			Status: orderStatusOf(n.ConsignorState),
+			Source: "callback",
+			Note:   note,
+		})
+		r.UpdatedAt = now
+		return nil
+	}
+
+	var note string
+	if len(skipped) > 0 {
+		names := make([]string, len(skipped))
+		for i, s := range skipped {
+			names[i] = s.Description()
+		}
+		note = "未收到" + strings.Join(names, "、") + "通知"
+	}
+	r.ConsignorState = n.ConsignorState
+	r.transition(orderStatusOf(n.ConsignorState), "callback", note, now)
+	return nil
+}
+
+// Get 按订单号查询记录，不存在时返回nil
+func (t *OrderTracker) Get(orderID string) (*OrderRecord, error) {
+	return t.store.Get(orderID)
+}
+
+// Find 按条件查询记录
+func (t *OrderTracker) Find(query OrderQuery) ([]*OrderRecord, error) {
+	return t.store.Find(query)
+}
+
+// FindBySelfComment 按自定义单号查询记录
+func (t *OrderTracker) FindBySelfComment(selfComment string) ([]*OrderRecord, error) {
+	return t.store.Find(OrderQuery{SelfComment: selfComment})
+}
+
+// update 在锁内读取、修改并保存订单记录，记录不存在时新建
+func (t *OrderTracker) update(orderID, selfComment string, fn func(r *OrderRecord, now time.Time) error) error {
+	if orderID == "" {
+		return errors.New("orderId is required")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, err := t.store.Get(orderID)
+	if err != nil {
+		return fmt.Errorf("读取订单记录失败: %w", err)
+	}
+	now := time.Now()
+	if record == nil {
+		record = &OrderRecord{OrderID: orderID, SelfComment: selfComment, CreatedAt: now}
+	}
+	if err := fn(record, now); err != nil {
+		return err
+	}
+	if err := t.store.Put(record); err != nil {
+		return fmt.Errorf("保存订单记录失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package zczy
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOrderTrackerLifecycle(t *testing.T) {
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		if method == MethodOrderCreateMore {
+			return &Response{Code: "0000", Result: map[string]string{"orderId": "ZC001"}}
+		}
+		return &Response{Code: "0000"}
+	})
+	tracker, err := NewOrderTracker(client, NewMemoryOrderStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if _, err := tracker.CreateOrder(newValidCreateOrderRequest()); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	delist := map[string]string{
+		"orderId":        "ZC001",
+		"selfComment":    "TEST001",
+		"consignorState": "5",
+		"carrierName":    "张三",
+		"plateNumber":    "苏A12345",
+		"driverUserName": "李四",
+		"delistTime":     "2025-01-20 09:00:00",
+	}
+	if err := tracker.HandleCallback(newSignedCallbackRequest(t, client, delist)); err != nil {
+		t.Fatalf("HandleCallback() error = %v", err)
+	}
+
+	// 直接收到确认收货：跳过确认发货
+	delist["consignorState"] = "7"
+	if err := tracker.HandleCallback(newSignedCallbackRequest(t, client, delist)); err != nil {
+		t.Fatalf("HandleCallback() error = %v", err)
+	}
+	// 确认发货晚到：只记录历史
+	delist["consignorState"] = "6"
+	if err := tracker.HandleCallback(newSignedCallbackRequest(t, client, delist)); err != nil {
+		t.Fatalf("HandleCallback() error = %v", err)
+	}
+
+	if err := tracker.ConfirmReceipt(&ConfirmReceiptRequest{OrderID: "ZC001", Tonnage: "30"}); err != nil {
+		t.Fatalf("ConfirmReceipt() error = %v", err)
+	}
+
+	r, err := tracker.Get("ZC001")
+	if err != nil || r == nil {
+		t.Fatalf("Get() = %v, %v", r, err)
+	}
+	if r.Status != OrderStatusReceiptConfirmed || r.ConsignorState != ConsignorStateDelivered {
+		t.Errorf("status = %s/%s", r.Status, r.ConsignorState)
+	}
+	if r.PlateNumber != "苏A12345" || r.DriverName != "李四" || r.Request == nil {
+		t.Errorf("record = %+v", r)
+	}
+
+	want := []OrderStatus{OrderStatusCreated, OrderStatusDelisted, OrderStatusDelivered, OrderStatusShipped, OrderStatusReceiptConfirmed}
+	if len(r.History) != len(want) {
+		t.Fatalf("History = %+v", r.History)
+	}
+	for i, status := range want {
+		if r.History[i].Status != status {
+			t.Errorf("History[%d].Status = %s, want %s", i, r.History[i].Status, status)
+		}
+	}
+	if r.History[2].Note == "" || r.History[3].Note == "" {
+		t.Errorf("跳过和乱序的状态应有说明: %+v", r.History)
+	}
+
+	if found, _ := tracker.FindBySelfComment("TEST001"); len(found) != 1 {
+		t.Errorf("FindBySelfComment() = %d条", len(found))
+	}
+	if found, _ := tracker.Find(OrderQuery{Status: OrderStatusReceiptConfirmed, From: start}); len(found) != 1 {
+		t.Errorf("Find() = %d条", len(found))
+	}
+	if found, _ := tracker.Find(OrderQuery{To: start}); len(found) != 0 {
+		t.Errorf("Find(To) = %d条", len(found))
+	}
+}
+
+func TestOrderTrackerCallbackOnly(t *testing.T) {
+	store, err := NewFileOrderStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker, _ := NewOrderTracker(newTestCallbackClient(), store)
+
+	if err := tracker.Apply(&DelistNotification{OrderID: "ZC002", SelfComment: "EXT", ConsignorState: ConsignorStateDelisted}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Apply(&BreachResultNotification{OrderID: "ZC002", IsStop: "1", Operation: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := store.Get("ZC002")
+	if err != nil || r == nil {
+		t.Fatalf("Get() = %v, %v", r, err)
+	}
+	if r.Status != OrderStatusTerminated || len(r.Breaches) != 1 || r.SelfComment != "EXT" {
+		t.Errorf("record = %+v", r)
+	}
+
+	// 确认收货后不允许变为已终止
+	err = tracker.Apply(&DelistNotification{OrderID: "ZC003", ConsignorState: ConsignorStateDelivered})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tracker.Apply(&DelistNotification{OrderID: "ZC003", ConsignorState: ConsignorStateTerminated})
+	if !errors.Is(err, ErrInvalidStateTransition) {
+		t.Errorf("Apply() error = %v, want ErrInvalidStateTransition", err)
+	}
+}
+
+func TestOrderTrackerLateDelistAfterCancel(t *testing.T) {
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		if method == MethodOrderCreateMore {
+			return &Response{Code: "0000", Result: map[string]string{"orderId": "ZC001"}}
+		}
+		return &Response{Code: "0000"}
+	})
+	tracker, _ := NewOrderTracker(client, NewMemoryOrderStore())
+	if _, err := tracker.CreateOrder(newValidCreateOrderRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.CancelOrder("ZC001"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 摘单通知晚于本地取消到达：保留已取消状态，冲突记录在历史中
+	if err := tracker.Apply(&DelistNotification{OrderID: "ZC001", ConsignorState: ConsignorStateDelisted, PlateNumber: "苏A12345"}); err != nil {
+		t.Fatal(err)
+	}
+	r, _ := tracker.Get("ZC001")
+	if r.Status != OrderStatusCancelled || r.ConsignorState != ConsignorStateDelisted || r.PlateNumber != "苏A12345" {
+		t.Errorf("record = %+v", r)
+	}
+	last := r.History[len(r.History)-1]
+	if last.Status != OrderStatusDelisted || last.Source != "callback" || last.Note == "" {
+		t.Errorf("冲突记录 = %+v", last)
+	}
+	if found, _ := tracker.Find(OrderQuery{Status: OrderStatusCancelled}); len(found) != 1 {
+		t.Errorf("Find(cancelled) = %d条", len(found))
+	}
+}
+
+func TestOrderTrackerDelistBeforeCreateResult(t *testing.T) {
+	var tracker *OrderTracker
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		// 创建接口返回前已收到摘单回调
+		err := tracker.Apply(&DelistNotification{OrderID: "ZC001", ConsignorState: ConsignorStateDelisted, PlateNumber: "苏A12345"})
+		if err != nil {
+			t.Error(err)
+		}
+		return &Response{Code: "0000", Result: map[string]string{"orderId": "ZC001"}}
+	})
+	tracker, _ = NewOrderTracker(client, NewMemoryOrderStore())
+
+	if _, err := tracker.CreateOrder(newValidCreateOrderRequest()); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	r, _ := tracker.Get("ZC001")
+	if r.Status != OrderStatusDelisted || r.PlateNumber != "苏A12345" || r.Request == nil || r.SelfComment != "TEST001" {
+		t.Errorf("record = %+v", r)
+	}
+	if len(r.History) != 2 || r.History[0].Status != OrderStatusDelisted {
+		t.Errorf("History = %+v", r.History)
+	}
+}
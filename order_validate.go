@@ -0,0 +1,411 @@
+package zczy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Path    string // 字段的JSON路径，例如 cargoList[1].weight
+	Message string // 错误描述
+	Err     error  // 导致错误的原因，可能为nil
+}
+
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Unwrap 返回导致错误的原因
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors 参数校验错误集合
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return "参数校验失败: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap 支持 errors.Is / errors.As 匹配其中的单个字段错误
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, fe := range e {
+		errs[i] = fe
+	}
+	return errs
+}
+
+// Field 返回指定路径的字段错误
+func (e ValidationErrors) Field(path string) *FieldError {
+	for _, fe := range e {
+		if fe.Path == path {
+			return fe
+		}
+	}
+	return nil
+}
+
+// validator 收集字段校验错误
+type validator struct {
+	errs ValidationErrors
+}
+
+// addf 添加一条字段错误
+func (v *validator) addf(path, format string, args ...any) {
+	v.errs = append(v.errs, &FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// err 返回收集到的错误，没有错误时返回nil
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// required 校验必填
+func (v *validator) required(path, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.addf(path, "不能为空")
+		return false
+	}
+	return true
+}
+
+// maxLen 校验最大长度（按字符计）
+func (v *validator) maxLen(path, value string, n int) {
+	if utf8.RuneCountInString(value) > n {
+		v.addf(path, "长度不能超过%d个字符", n)
+	}
+}
+
+// oneOf 校验取值范围，空值不校验
+func (v *validator) oneOf(path, value string, options ...string) {
+	if value == "" {
+		return
+	}
+	for _, option := range options {
+		if value == option {
+			return
+		}
+	}
+	v.addf(path, "取值必须为%s之一，实际为%q", strings.Join(options, "/"), value)
+}
+
+// enum 校验枚举取值（中文取值或其别名），空值不校验
+func (v *validator) enum(path, value string, spec enumSpec) {
+	if value == "" {
+		return
+	}
+	if _, err := spec.parse(value); err != nil {
+		v.addf(path, "取值必须为%s之一，实际为%q", strings.Join(spec.values, "/"), value)
+	}
+}
+
+// decimal 校验非负小数格式及小数位数，空值不校验
+func (v *validator) decimal(path, value string, maxScale int) {
+	if value == "" {
+		return
+	}
+	intPart, fracPart, hasDot := strings.Cut(value, ".")
+	if intPart == "" || !isDigits(intPart) || (hasDot && (fracPart == "" || !isDigits(fracPart))) {
+		v.addf(path, "必须为非负数字，实际为%q", value)
+		return
+	}
+	if len(fracPart) > maxScale {
+		v.addf(path, "小数位数不能超过%d位，实际为%q", maxScale, value)
+	}
+}
+
+// money 校验金额格式、小数位数且不能为负，空值不校验
+func (v *validator) money(path string, m Money) {
+	v.scaled(path, string(m), m.Validate())
+}
+
+// quantity 校验重量格式、小数位数且不能为负，空值不校验
+func (v *validator) quantity(path string, q Quantity) {
+	v.scaled(path, string(q), q.Validate())
+}
+
+// scaled 记录金额/重量的校验结果
+func (v *validator) scaled(path, value string, err error) {
+	if err != nil {
+		v.addf(path, "%v", err)
+		return
+	}
+	if d, _ := ParseDecimal(value); d.Sign() < 0 {
+		v.addf(path, "不能为负数，实际为%q", value)
+	}
+}
+
+// percent 校验百分比（大于0且不超过100），空值不校验
+func (v *validator) percent(path, value string) {
+	v.percentRange(path, value, false)
+}
+
+// ratio 校验分配比例（0-100），允许为0，空值不校验
+func (v *validator) ratio(path, value string) {
+	v.percentRange(path, value, true)
+}
+
+// percentRange 校验百分比格式和范围，allowZero 为true时允许为0
+func (v *validator) percentRange(path, value string, allowZero bool) {
+	if value == "" {
+		return
+	}
+	before := len(v.errs)
+	v.decimal(path, value, 2)
+	if len(v.errs) > before {
+		return
+	}
+	d, _ := ParseDecimal(value)
+	switch {
+	case allowZero && (d.Sign() < 0 || d.Cmp(NewDecimal(100, 0)) > 0):
+		v.addf(path, "必须在0到100之间，实际为%q", value)
+	case !allowZero && (d.Sign() <= 0 || d.Cmp(NewDecimal(100, 0)) > 0):
+		v.addf(path, "必须大于0且不超过100，实际为%q", value)
+	}
+}
+
+// platformTime 校验时间格式，返回解析后的时间，空值或格式错误时返回零值
+func (v *validator) platformTime(path string, value PlatformTime) time.Time {
+	if value.IsZero() {
+		return time.Time{}
+	}
+	t, err := value.Time()
+	if err != nil {
+		v.addf(path, "%v", err)
+	}
+	return t
+}
+
+// requiredTime 校验必填的时间
+func (v *validator) requiredTime(path string, value PlatformTime) time.Time {
+	if !v.required(path, string(value)) {
+		return time.Time{}
+	}
+	return v.platformTime(path, value)
+}
+
+// before 校验时间先后顺序，任一时间为零值时不校验
+func (v *validator) before(path string, earlier, later time.Time, message string) {
+	if earlier.IsZero() || later.IsZero() {
+		return
+	}
+	if !earlier.Before(later) {
+		v.addf(path, "%s", message)
+	}
+}
+
+// isDigits 判断字符串是否全部为数字
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Validate 校验创建订单请求，返回所有问题
+// 返回的错误为 ValidationErrors，每一项带有字段的JSON路径，例如 cargoList[1].weight
+func (r *CreateOrderRequest) Validate() error {
+	v := &validator{}
+	r.validate(v)
+	return v.err()
+}
+
+// validate 校验创建订单请求的全部字段
+func (r *CreateOrderRequest) validate(v *validator) {
+	r.OrderInfo.validate(v, "orderInfo")
+
+	if len(r.CargoList) == 0 {
+		v.addf("cargoList", "至少需要一条货物信息")
+	}
+	for i := range r.CargoList {
+		r.CargoList[i].validate(v, fmt.Sprintf("cargoList[%d]", i))
+	}
+
+	if freight, ok := r.freight(); ok {
+		OilGasAllocationOf(&r.OrderInfo).validateFreight(v, "orderInfo", freight)
+	}
+
+	r.OrderAddressInfo.validate(v, "orderAddressInfo")
+	r.OrderReceiptInfo.validate(v, "orderReceiptInfo")
+}
+
+// freight 返回按运费（TotalAmount）计算的总运费，单价订单为单价 × 货物总重量
+// 运费为空或格式错误时返回false
+func (r *CreateOrderRequest) freight() (Money, bool) {
+	price, err := r.OrderInfo.TotalAmount.Decimal()
+	if r.OrderInfo.TotalAmount == "" || err != nil {
+		return "", false
+	}
+	switch model, _ := ParseFreightType(string(r.OrderInfo.FreightType)); model {
+	case FreightTypeWholeTruck:
+		return r.OrderInfo.TotalAmount, true
+	case FreightTypeUnitPrice:
+		var weight Decimal
+		for _, cargo := range r.CargoList {
+			w, err := cargo.Weight.Decimal()
+			if err != nil {
+				return "", false
+			}
+			weight = weight.Add(w)
+		}
+		return NewMoney(price.Mul(weight), RoundHalfUp), true
+	default:
+		return "", false
+	}
+}
+
+// validate 校验订单信息
+func (o *OrderInfo) validate(v *validator, prefix string) {
+	p := func(field string) string { return prefix + "." + field }
+
+	if v.required(p("orderModel"), string(o.OrderModel)) {
+		v.enum(p("orderModel"), string(o.OrderModel), orderModelSpec)
+	}
+	if v.required(p("freightType"), string(o.FreightType)) {
+		v.enum(p("freightType"), string(o.FreightType), freightTypeSpec)
+	}
+	v.maxLen(p("selfComment"), o.SelfComment, 64)
+	if v.required(p("contactName"), o.ContactName) {
+		v.maxLen(p("contactName"), o.ContactName, 20)
+	}
+	if v.required(p("contactPhone"), o.ContactPhone) {
+		v.phone(p("contactPhone"), o.ContactPhone)
+	}
+	v.phone(p("pickOrderAdvisoryPhone"), o.PickOrderAdvisoryPhone)
+	v.phone(p("settlementAdvisoryPhone"), o.SettlementAdvisoryPhone)
+	v.required(p("vehicleType"), o.VehicleType)
+	if v.required(p("vehicleLength"), o.VehicleLength) {
+		v.decimal(p("vehicleLength"), o.VehicleLength, 1)
+	}
+
+	for _, f := range []struct {
+		field string
+		value YesNo
+	}{
+		{"urgentFlag", o.UrgentFlag},
+		{"advanceFlag", o.AdvanceFlag},
+		{"receiptFlag", o.ReceiptFlag},
+	} {
+		if v.required(p(f.field), string(f.value)) {
+			v.enum(p(f.field), string(f.value), yesNoSpec)
+		}
+	}
+	v.enum(p("supportSdOilCardFlag"), string(o.SupportSdOilCardFlag), yesNoSpec)
+
+	despatchStart := v.requiredTime(p("despatchStart"), o.DespatchStart)
+	despatchEnd := v.requiredTime(p("despatchEnd"), o.DespatchEnd)
+	receiveDate := v.requiredTime(p("receiveDate"), o.ReceiveDate)
+	expectTime := v.platformTime(p("expectTime"), o.ExpectTime)
+
+	// 时间先后顺序：报价结束 < 装货开始 < 装货结束 < 收货
+	v.before(p("despatchEnd"), despatchStart, despatchEnd, "装货结束时间必须晚于装货开始时间")
+	v.before(p("receiveDate"), despatchEnd, receiveDate, "收货时间必须晚于装货结束时间")
+	v.before(p("expectTime"), expectTime, despatchStart, "报价结束时间必须早于装货开始时间")
+
+	if v.required(p("cargoMoney"), string(o.CargoMoney)) {
+		v.money(p("cargoMoney"), o.CargoMoney)
+	}
+	v.money(p("totalAmount"), o.TotalAmount)
+	v.money(p("consignorNoTaxMoney"), o.ConsignorNoTaxMoney)
+	v.money(p("interceptPrice"), o.InterceptPrice)
+	v.percent(p("advanceRatio"), o.AdvanceRatio)
+	v.required(p("settleBasis"), o.SettleBasis)
+	v.maxLen(p("prompt"), o.Prompt, 500)
+
+	// 条件必填
+	model, _ := ParseOrderModel(string(o.OrderModel))
+	switch model {
+	case OrderModelBidding:
+		v.required(p("expectTime"), string(o.ExpectTime))
+	case OrderModelGrab:
+		if o.TotalAmount == "" && o.ConsignorNoTaxMoney == "" {
+			v.addf(p("totalAmount"), "抢单时运费与承运方预估到手价至少填写一项")
+		}
+	}
+	if flag, _ := ParseYesNo(string(o.AdvanceFlag)); flag == Yes {
+		v.required(p("advanceRatio"), o.AdvanceRatio)
+	}
+
+	oilGas := OilGasAllocation{
+		OilRatio: o.OilCardRatio,
+		GasRatio: o.GasPercent,
+		OilFixed: o.OilFixedCredit,
+		GasFixed: o.GasFixedCredit,
+	}
+	oilGas.validate(v, prefix)
+	if flag, _ := ParseYesNo(string(o.SupportSdOilCardFlag)); flag == Yes && oilGas.Mode() == OilGasNone {
+		v.addf(p("supportSdOilCardFlag"), "包含油气品时需要填写油气品比例或固定额度")
+	}
+}
+
+// validate 校验货物信息
+func (c *CargoInfo) validate(v *validator, prefix string) {
+	p := func(field string) string { return prefix + "." + field }
+
+	if v.required(p("cargoName"), c.CargoName) {
+		v.maxLen(p("cargoName"), c.CargoName, 50)
+	}
+	if v.required(p("cargoCategory"), string(c.CargoCategory)) {
+		v.enum(p("cargoCategory"), string(c.CargoCategory), cargoCategorySpec)
+	}
+	if v.required(p("weight"), string(c.Weight)) {
+		before := len(v.errs)
+		v.quantity(p("weight"), c.Weight)
+		if d, _ := c.Weight.Decimal(); len(v.errs) == before && d.Sign() == 0 {
+			v.addf(p("weight"), "必须大于0，实际为%q", c.Weight)
+		}
+	}
+	v.decimal(p("cargoLength"), c.CargoLength, 2)
+	v.decimal(p("cargoWidth"), c.CargoWidth, 2)
+	v.decimal(p("cargoHeight"), c.CargoHeight, 2)
+	v.required(p("pack"), c.Pack)
+}
+
+// validate 校验收发货信息
+func (a *OrderAddressInfo) validate(v *validator, prefix string) {
+	p := func(field string) string { return prefix + "." + field }
+
+	v.required(p("despatchCompanyName"), a.DespatchCompanyName)
+	v.required(p("despatchName"), a.DespatchName)
+	if v.required(p("despatchMobile"), a.DespatchMobile) {
+		v.phone(p("despatchMobile"), a.DespatchMobile)
+	}
+	v.phone(p("despatchBackupMobile"), a.DespatchBackupMobile)
+	v.required(p("despatchPro"), a.DespatchPro)
+	v.required(p("despatchCity"), a.DespatchCity)
+	v.required(p("despatchDis"), a.DespatchDis)
+	if v.required(p("despatchPlace"), a.DespatchPlace) {
+		v.maxLen(p("despatchPlace"), a.DespatchPlace, 200)
+	}
+
+	v.required(p("deliverCompanyName"), a.DeliverCompanyName)
+	v.required(p("deliverName"), a.DeliverName)
+	if v.required(p("deliverMobile"), a.DeliverMobile) {
+		v.phone(p("deliverMobile"), a.DeliverMobile)
+	}
+	v.phone(p("deliverBackupMobile"), a.DeliverBackupMobile)
+	v.required(p("deliverPro"), a.DeliverPro)
+	v.required(p("deliverCity"), a.DeliverCity)
+	v.required(p("deliverDis"), a.DeliverDis)
+	if v.required(p("deliverPlace"), a.DeliverPlace) {
+		v.maxLen(p("deliverPlace"), a.DeliverPlace, 200)
+	}
+}
+
+// validate 校验押回单信息
+func (r *OrderReceiptInfo) validate(v *validator, prefix string) {
+	v.money(prefix+".receiptMoney", r.ReceiptMoney)
+}
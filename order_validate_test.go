@@ -0,0 +1,121 @@
+package zczy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newValidCreateOrderRequest 构建一个可以通过校验的创建订单请求
+func newValidCreateOrderRequest() *CreateOrderRequest {
+	return &CreateOrderRequest{
+		OrderInfo: OrderInfo{
+			OrderModel:     "抢单",
+			FreightType:    "单价",
+			SelfComment:    "TEST001",
+			ContactName:    "赵先生",
+			ContactPhone:   "13800000000",
+			VehicleType:    "高栏车",
+			VehicleLength:  "12",
+			UrgentFlag:     "否",
+			DespatchStart:  "2025-01-20 08:00",
+			DespatchEnd:    "2025-01-20 12:00",
+			ReceiveDate:    "2025-01-25 12:00",
+			TotalAmount:    "5000.00",
+			CargoMoney:     "50000.00",
+			AdvanceFlag:    "是",
+			AdvanceRatio:   "30",
+			ReceiptFlag:    "否",
+			PolicyFlag:     "1",
+			SettleBasis:    "按发货磅单结算",
+			InterceptPrice: "5500",
+		},
+		CargoList: []CargoInfo{
+			{
+				CargoName:     "钢材",
+				CargoCategory: "重货",
+				Weight:        "30.0",
+				Pack:          "捆",
+			},
+		},
+		OrderAddressInfo: OrderAddressInfo{
+			DespatchCompanyName: "发货公司",
+			DespatchName:        "李先生",
+			DespatchMobile:      "13800000001",
+			DespatchPro:         "江苏省",
+			DespatchCity:        "南京市",
+			DespatchDis:         "鼓楼区",
+			DespatchPlace:       "燕江路201号",
+			DeliverCompanyName:  "收货公司",
+			DeliverName:         "王先生",
+			DeliverMobile:       "13800000002",
+			DeliverPro:          "上海市",
+			DeliverCity:         "上海市",
+			DeliverDis:          "浦东新区",
+			DeliverPlace:        "张江高科技园区100号",
+		},
+	}
+}
+
+func TestCreateOrderRequestValidate(t *testing.T) {
+	if err := newValidCreateOrderRequest().Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(r *CreateOrderRequest)
+		wantPath string
+	}{
+		{"竞价缺少报价结束时间", func(r *CreateOrderRequest) { r.OrderInfo.OrderModel = "竞价" }, "orderInfo.expectTime"},
+		{"抢单缺少运费", func(r *CreateOrderRequest) { r.OrderInfo.TotalAmount = "" }, "orderInfo.totalAmount"},
+		{"预付缺少比例", func(r *CreateOrderRequest) { r.OrderInfo.AdvanceRatio = "" }, "orderInfo.advanceRatio"},
+		{"预付比例超过100", func(r *CreateOrderRequest) { r.OrderInfo.AdvanceRatio = "120" }, "orderInfo.advanceRatio"},
+		{"未知订单类型", func(r *CreateOrderRequest) { r.OrderInfo.OrderModel = "拍卖" }, "orderInfo.orderModel"},
+		{"金额小数位过多", func(r *CreateOrderRequest) { r.OrderInfo.TotalAmount = "5500.0000001" }, "orderInfo.totalAmount"},
+		{"时间格式错误", func(r *CreateOrderRequest) { r.OrderInfo.DespatchStart = "2025/01/20" }, "orderInfo.despatchStart"},
+		{"缺少货物", func(r *CreateOrderRequest) { r.CargoList = nil }, "cargoList"},
+		{"第二个货物缺少重量", func(r *CreateOrderRequest) {
+			r.CargoList = append(r.CargoList, CargoInfo{CargoName: "铝材", CargoCategory: "重货", Pack: "捆"})
+		}, "cargoList[1].weight"},
+		{"货物重量为0", func(r *CreateOrderRequest) { r.CargoList[0].Weight = "0" }, "cargoList[0].weight"},
+		{"缺少收货人电话", func(r *CreateOrderRequest) { r.OrderAddressInfo.DeliverMobile = "" }, "orderAddressInfo.deliverMobile"},
+		{"自定义单号过长", func(r *CreateOrderRequest) { r.OrderInfo.SelfComment = strings.Repeat("A", 65) }, "orderInfo.selfComment"},
+		{"联系人过长", func(r *CreateOrderRequest) { r.OrderInfo.ContactName = strings.Repeat("赵", 21) }, "orderInfo.contactName"},
+		{"货物名称过长", func(r *CreateOrderRequest) { r.CargoList[0].CargoName = strings.Repeat("钢", 51) }, "cargoList[0].cargoName"},
+		{"收货地址过长", func(r *CreateOrderRequest) { r.OrderAddressInfo.DeliverPlace = strings.Repeat("路", 201) }, "orderAddressInfo.deliverPlace"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newValidCreateOrderRequest()
+			tt.modify(req)
+
+			err := req.Validate()
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+			}
+			if errs.Field(tt.wantPath) == nil {
+				t.Errorf("缺少字段 %s 的错误，实际: %v", tt.wantPath, err)
+			}
+		})
+	}
+}
+
+func TestCreateOrderRequestValidateCollectsAll(t *testing.T) {
+	err := (&CreateOrderRequest{}).Validate()
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+	}
+	if len(errs) < 10 {
+		t.Errorf("应返回全部问题，实际只有%d条: %v", len(errs), err)
+	}
+
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Errorf("应能通过errors.As取得单个FieldError")
+	}
+}
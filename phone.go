@@ -0,0 +1,208 @@
+package zczy
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidPhone 电话号码格式错误
+var ErrInvalidPhone = errors.New("电话号码格式错误")
+
+// PhoneKind 电话号码类型
+type PhoneKind int
+
+const (
+	// PhoneUnknown 无法识别
+	PhoneUnknown PhoneKind = iota
+	// PhoneMobile 手机号，11位，以1开头
+	PhoneMobile
+	// PhoneLandline 固定电话，区号 + 7-8位号码
+	PhoneLandline
+	// PhoneService 400/800服务电话
+	PhoneService
+)
+
+// NormalizePhone 规范化电话号码，返回规范形式和类型
+//
+// 去掉空格、"-"、括号及 +86/0086 前缀后识别，带国际前缀的固定电话补回长途前缀0（+86 25 8456 1234 → 025-84561234）：
+//   - 手机号：13812345678
+//   - 固定电话：区号与号码用"-"连接，例如 025-84561234、0755-8888888
+//   - 400/800服务电话：4001234567
+func NormalizePhone(s string) (string, PhoneKind, error) {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '（', '）', '　':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+	international := false
+	for _, prefix := range []string{"+86", "0086"} {
+		if rest, ok := strings.CutPrefix(digits, prefix); ok {
+			digits, international = rest, true
+		}
+	}
+	if len(digits) == 13 && strings.HasPrefix(digits, "861") {
+		digits = digits[2:]
+	}
+	mobile := len(digits) == 11 && digits[0] == '1'
+	service := len(digits) == 10 && (strings.HasPrefix(digits, "400") || strings.HasPrefix(digits, "800"))
+	if international && digits != "" && digits[0] != '0' && !mobile && !service {
+		digits = "0" + digits
+	}
+
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", PhoneUnknown, fmt.Errorf("%w: %q", ErrInvalidPhone, s)
+		}
+	}
+
+	switch {
+	case mobile && digits[1] >= '3':
+		return digits, PhoneMobile, nil
+	case service:
+		return digits, PhoneService, nil
+	case len(digits) > 0 && digits[0] == '0':
+		// 010、02X为3位区号，其余为4位区号
+		area := 4
+		if strings.HasPrefix(digits, "010") || strings.HasPrefix(digits, "02") {
+			area = 3
+		}
+		if n := len(digits) - area; n >= 7 && n <= 8 && digits[area] != '0' {
+			return digits[:area] + "-" + digits[area:], PhoneLandline, nil
+		}
+	}
+	return "", PhoneUnknown, fmt.Errorf("%w: %q", ErrInvalidPhone, s)
+}
+
+// MaskPhone 返回脱敏后的电话号码，用于日志输出
+// 手机号保留前3位和后4位（138****0000），固定电话保留区号和后4位（025-****1234），
+// 无法识别的号码只保留首尾各2个字符
+func MaskPhone(s string) string {
+	if s == "" {
+		return ""
+	}
+	phone, kind, err := NormalizePhone(s)
+	switch {
+	case err != nil:
+		if n := utf8.RuneCountInString(s); n > 4 {
+			r := []rune(s)
+			return string(r[:2]) + strings.Repeat("*", n-4) + string(r[n-2:])
+		}
+		return "****"
+	case kind == PhoneLandline:
+		i := strings.Index(phone, "-")
+		return phone[:i+1] + strings.Repeat("*", len(phone)-i-5) + phone[len(phone)-4:]
+	default:
+		return phone[:3] + strings.Repeat("*", len(phone)-7) + phone[len(phone)-4:]
+	}
+}
+
+// phone 校验电话号码（手机、固定电话或400/800），空值不校验
+func (v *validator) phone(path, value string) {
+	if value == "" {
+		return
+	}
+	if _, _, err := NormalizePhone(value); err != nil {
+		v.addf(path, "电话号码格式错误，实际为%q", value)
+	}
+}
+
+// NormalizePhones 将订单中的联系电话替换为规范形式
+// 无法识别的号码保持不变，返回 ValidationErrors
+func (r *CreateOrderRequest) NormalizePhones() error {
+	v := &validator{}
+	for _, f := range []struct {
+		path  string
+		value *string
+	}{
+		{"orderInfo.contactPhone", &r.OrderInfo.ContactPhone},
+		{"orderInfo.pickOrderAdvisoryPhone", &r.OrderInfo.PickOrderAdvisoryPhone},
+		{"orderInfo.settlementAdvisoryPhone", &r.OrderInfo.SettlementAdvisoryPhone},
+		{"orderAddressInfo.despatchMobile", &r.OrderAddressInfo.DespatchMobile},
+		{"orderAddressInfo.despatchBackupMobile", &r.OrderAddressInfo.DespatchBackupMobile},
+		{"orderAddressInfo.deliverMobile", &r.OrderAddressInfo.DeliverMobile},
+		{"orderAddressInfo.deliverBackupMobile", &r.OrderAddressInfo.DeliverBackupMobile},
+	} {
+		if *f.value == "" {
+			continue
+		}
+		phone, _, err := NormalizePhone(*f.value)
+		if err != nil {
+			v.addf(f.path, "电话号码格式错误，实际为%q", *f.value)
+			continue
+		}
+		*f.value = phone
+	}
+	return v.err()
+}
+
+// maskedDelistNotification 与 DelistNotification 字段相同，不带 String/LogValue 方法
+type maskedDelistNotification DelistNotification
+
+// masked 返回手机号脱敏后的副本
+func (n DelistNotification) masked() maskedDelistNotification {
+	m := maskedDelistNotification(n)
+	m.ConsignorMobile = MaskPhone(n.ConsignorMobile)
+	m.CarrierMobile = MaskPhone(n.CarrierMobile)
+	m.DriverMobile = MaskPhone(n.DriverMobile)
+	return m
+}
+
+// String 返回手机号脱敏后的内容，避免日志中出现完整手机号
+func (n DelistNotification) String() string {
+	return fmt.Sprintf("%+v", n.masked())
+}
+
+// LogValue 实现 slog.LogValuer，输出手机号脱敏后的内容
+func (n DelistNotification) LogValue() slog.Value {
+	return slog.AnyValue(n.masked())
+}
+
+// maskedOrderInfo 与 OrderInfo 字段相同，不带 String/LogValue 方法
+type maskedOrderInfo OrderInfo
+
+// masked 返回电话号码脱敏后的副本
+func (o OrderInfo) masked() maskedOrderInfo {
+	m := maskedOrderInfo(o)
+	m.ContactPhone = MaskPhone(o.ContactPhone)
+	m.PickOrderAdvisoryPhone = MaskPhone(o.PickOrderAdvisoryPhone)
+	m.SettlementAdvisoryPhone = MaskPhone(o.SettlementAdvisoryPhone)
+	return m
+}
+
+// String 返回电话号码脱敏后的内容，避免日志中出现完整电话号码
+func (o OrderInfo) String() string {
+	return fmt.Sprintf("%+v", o.masked())
+}
+
+// LogValue 实现 slog.LogValuer，输出电话号码脱敏后的内容
+func (o OrderInfo) LogValue() slog.Value {
+	return slog.AnyValue(o.masked())
+}
+
+// maskedOrderAddressInfo 与 OrderAddressInfo 字段相同，不带 String/LogValue 方法
+type maskedOrderAddressInfo OrderAddressInfo
+
+// masked 返回电话号码脱敏后的副本
+func (a OrderAddressInfo) masked() maskedOrderAddressInfo {
+	m := maskedOrderAddressInfo(a)
+	m.DespatchMobile = MaskPhone(a.DespatchMobile)
+	m.DespatchBackupMobile = MaskPhone(a.DespatchBackupMobile)
+	m.DeliverMobile = MaskPhone(a.DeliverMobile)
+	m.DeliverBackupMobile = MaskPhone(a.DeliverBackupMobile)
+	return m
+}
+
+// String 返回电话号码脱敏后的内容，避免日志中出现完整电话号码
+func (a OrderAddressInfo) String() string {
+	return fmt.Sprintf("%+v", a.masked())
+}
+
+// LogValue 实现 slog.LogValuer，输出电话号码脱敏后的内容
+func (a OrderAddressInfo) LogValue() slog.Value {
+	return slog.AnyValue(a.masked())
+}
@@ -0,0 +1,158 @@
+package zczy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		input    string
+		want     string
+		wantKind PhoneKind
+	}{
+		{"13812345678", "13812345678", PhoneMobile},
+		{"+86 138-1234-5678", "13812345678", PhoneMobile},
+		{"0086 13812345678", "13812345678", PhoneMobile},
+		{"8613812345678", "13812345678", PhoneMobile},
+		{" 138 1234 5678 ", "13812345678", PhoneMobile},
+		{"025-84561234", "025-84561234", PhoneLandline},
+		{"(010)12345678", "010-12345678", PhoneLandline},
+		{"07558888888", "0755-8888888", PhoneLandline},
+		{"0571 88886666", "0571-88886666", PhoneLandline},
+		{"+86 25 8456 1234", "025-84561234", PhoneLandline},
+		{"+86 10 1234 5678", "010-12345678", PhoneLandline},
+		{"0086-755-8888888", "0755-8888888", PhoneLandline},
+		{"+86 025 8456 1234", "025-84561234", PhoneLandline},
+		{"+86 400 123 4567", "4001234567", PhoneService},
+		{"400-123-4567", "4001234567", PhoneService},
+	}
+	for _, tt := range tests {
+		got, kind, err := NormalizePhone(tt.input)
+		if err != nil {
+			t.Errorf("NormalizePhone(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got != tt.want || kind != tt.wantKind {
+			t.Errorf("NormalizePhone(%q) = %q, %d, want %q, %d", tt.input, got, kind, tt.want, tt.wantKind)
+		}
+	}
+
+	for _, input := range []string{"", "1381234567", "12812345678", "138123456789", "13800xxxxxx", "025-1234", "025-0123456"} {
+		if _, _, err := NormalizePhone(input); !errors.Is(err, ErrInvalidPhone) {
+			t.Errorf("NormalizePhone(%q) error = %v, want ErrInvalidPhone", input, err)
+		}
+	}
+}
+
+func TestMaskPhone(t *testing.T) {
+	tests := map[string]string{
+		"13800000000":    "138****0000",
+		"+86 1380000123": "+8**********23",
+		"+8613800000123": "138****0123",
+		"025-84561234":   "025-****1234",
+		"07558888888":    "0755-***8888",
+		"4001234567":     "400***4567",
+		"abc":            "****",
+		"":               "",
+	}
+	for input, want := range tests {
+		if got := MaskPhone(input); got != want {
+			t.Errorf("MaskPhone(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestCreateOrderRequestPhones(t *testing.T) {
+	req := newValidCreateOrderRequest()
+	req.OrderInfo.ContactPhone = "+86 138-0000-0000"
+	req.OrderAddressInfo.DespatchBackupMobile = "(025)84561234"
+	req.OrderAddressInfo.DeliverMobile = "138000"
+
+	var errs ValidationErrors
+	if !errors.As(req.Validate(), &errs) || len(errs) != 1 || errs.Field("orderAddressInfo.deliverMobile") == nil {
+		t.Fatalf("Validate() error = %v, want 仅 deliverMobile 错误", errs)
+	}
+
+	if !errors.As(req.NormalizePhones(), &errs) || errs.Field("orderAddressInfo.deliverMobile") == nil {
+		t.Fatalf("NormalizePhones() error = %v, want deliverMobile 错误", errs)
+	}
+	if req.OrderInfo.ContactPhone != "13800000000" || req.OrderAddressInfo.DespatchBackupMobile != "025-84561234" {
+		t.Errorf("NormalizePhones() = %q, %q", req.OrderInfo.ContactPhone, req.OrderAddressInfo.DespatchBackupMobile)
+	}
+	if req.OrderAddressInfo.DeliverMobile != "138000" {
+		t.Errorf("无法识别的号码应保持不变，实际 %q", req.OrderAddressInfo.DeliverMobile)
+	}
+}
+
+func TestDelistNotificationMasked(t *testing.T) {
+	n := &DelistNotification{
+		OrderID:         "ORDER001",
+		ConsignorMobile: "13712345678",
+		CarrierMobile:   "13687654321",
+		DriverMobile:    "13598765432",
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Info("摘单", "notification", n)
+
+	for name, out := range map[string]string{
+		"String":   fmt.Sprint(n),
+		"%+v":      fmt.Sprintf("%+v", *n),
+		"LogValue": buf.String(),
+	} {
+		for _, phone := range []string{"13712345678", "13687654321", "13598765432"} {
+			if strings.Contains(out, phone) {
+				t.Errorf("%s 输出包含完整手机号 %s: %s", name, phone, out)
+			}
+		}
+		if !strings.Contains(out, "137****5678") || !strings.Contains(out, "ORDER001") {
+			t.Errorf("%s 输出缺少脱敏手机号或订单号: %s", name, out)
+		}
+	}
+	if n.ConsignorMobile != "13712345678" {
+		t.Error("脱敏不应修改原始数据")
+	}
+}
+
+func TestOrderAddressInfoMasked(t *testing.T) {
+	a := OrderAddressInfo{DespatchMobile: "13800000001", DeliverBackupMobile: "025-84561234", DeliverPro: "上海市"}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("地址", "address", a)
+
+	for _, out := range []string{a.String(), buf.String()} {
+		if strings.Contains(out, "13800000001") || strings.Contains(out, "84561234") {
+			t.Errorf("输出包含完整电话号码: %s", out)
+		}
+		if !strings.Contains(out, "138****0001") || !strings.Contains(out, "上海市") {
+			t.Errorf("输出缺少脱敏电话或地址: %s", out)
+		}
+	}
+}
+
+func TestOrderInfoMasked(t *testing.T) {
+	req := newValidCreateOrderRequest()
+	req.OrderInfo.ContactPhone = "13900000009"
+	req.OrderInfo.PickOrderAdvisoryPhone = "025-84561234"
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("订单", "orderInfo", req.OrderInfo)
+
+	for _, out := range []string{req.OrderInfo.String(), fmt.Sprintf("%+v", *req), buf.String()} {
+		if strings.Contains(out, "13900000009") || strings.Contains(out, "84561234") {
+			t.Errorf("输出包含完整电话号码: %s", out)
+		}
+		if !strings.Contains(out, "139****0009") || !strings.Contains(out, "TEST001") {
+			t.Errorf("输出缺少脱敏电话或自定义单号: %s", out)
+		}
+	}
+	if req.OrderInfo.ContactPhone != "13900000009" {
+		t.Error("脱敏不应修改原始数据")
+	}
+}
@@ -0,0 +1,73 @@
+package zczy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// PlatformMinuteLayout 平台时间格式（精确到分钟），用于装货、收货、报价结束及轨迹查询时间
+	PlatformMinuteLayout = "2006-01-02 15:04"
+	// PlatformSecondLayout 平台时间格式（精确到秒），用于摘牌时间等回调字段
+	PlatformSecondLayout = "2006-01-02 15:04:05"
+)
+
+// ChinaLocation 中国标准时间（UTC+8）
+// 使用固定时区而不是time.LoadLocation，避免依赖服务器上的时区数据库
+var ChinaLocation = time.FixedZone("CST", 8*60*60)
+
+// PlatformTime 平台时间，在JSON中以 yyyy-mm-dd hh:mm 或 yyyy-mm-dd hh:mm:ss 字符串传输
+// 始终按中国标准时间解释，与服务器所在时区无关
+type PlatformTime string
+
+// NewPlatformTime 将时间转换为中国标准时间并格式化为 yyyy-mm-dd hh:mm
+func NewPlatformTime(t time.Time) PlatformTime {
+	return PlatformTime(t.In(ChinaLocation).Format(PlatformMinuteLayout))
+}
+
+// NewPlatformTimeSeconds 将时间转换为中国标准时间并格式化为 yyyy-mm-dd hh:mm:ss
+func NewPlatformTimeSeconds(t time.Time) PlatformTime {
+	return PlatformTime(t.In(ChinaLocation).Format(PlatformSecondLayout))
+}
+
+// ParsePlatformTime 解析并校验平台时间字符串
+func ParsePlatformTime(s string) (PlatformTime, error) {
+	p := PlatformTime(strings.TrimSpace(s))
+	if _, err := p.Time(); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// Time 按中国标准时间解析，支持精确到分钟和精确到秒两种格式
+func (p PlatformTime) Time() (time.Time, error) {
+	s := strings.TrimSpace(string(p))
+	for _, layout := range []string{PlatformMinuteLayout, PlatformSecondLayout} {
+		if t, err := time.ParseInLocation(layout, s, ChinaLocation); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("时间格式必须为yyyy-mm-dd hh:mm或yyyy-mm-dd hh:mm:ss: %q", string(p))
+}
+
+// IsZero 判断是否未填写
+func (p PlatformTime) IsZero() bool {
+	return strings.TrimSpace(string(p)) == ""
+}
+
+// Validate 校验时间格式，空值视为未填写，不报错
+func (p PlatformTime) Validate() error {
+	if p.IsZero() {
+		return nil
+	}
+	_, err := p.Time()
+	return err
+}
+
+// MarshalJSON 原样输出字符串（去掉首尾空格），格式由 Validate 校验
+// 回调中平台返回的时间可能不是约定的格式，需要能够原样重新序列化
+func (p PlatformTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.TrimSpace(string(p)))
+}
@@ -0,0 +1,97 @@
+package zczy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlatformTimeZone(t *testing.T) {
+	// 无论服务器时区如何，都按中国标准时间格式化和解析
+	utc := time.Date(2025, 1, 20, 0, 30, 0, 0, time.UTC)
+	if got := NewPlatformTime(utc); got != "2025-01-20 08:30" {
+		t.Errorf("NewPlatformTime() = %s, want 2025-01-20 08:30", got)
+	}
+	if got := NewPlatformTimeSeconds(utc); got != "2025-01-20 08:30:00" {
+		t.Errorf("NewPlatformTimeSeconds() = %s, want 2025-01-20 08:30:00", got)
+	}
+
+	parsed, err := PlatformTime("2025-01-20 08:30").Time()
+	if err != nil {
+		t.Fatalf("Time() error = %v", err)
+	}
+	if !parsed.Equal(utc) {
+		t.Errorf("Time() = %v, want %v", parsed, utc)
+	}
+
+	withSeconds, err := PlatformTime("2025-01-18 10:00:00").Time()
+	if err != nil {
+		t.Fatalf("Time() error = %v", err)
+	}
+	if withSeconds.UTC().Hour() != 2 {
+		t.Errorf("Time() = %v, want 02:00 UTC", withSeconds.UTC())
+	}
+}
+
+func TestPlatformTimeValidate(t *testing.T) {
+	for _, valid := range []PlatformTime{"", "2021-08-02 12:20", "2021-08-02 12:20:30"} {
+		if err := valid.Validate(); err != nil {
+			t.Errorf("Validate(%q) error = %v", valid, err)
+		}
+	}
+	for _, invalid := range []PlatformTime{"2021/08/02 12:20", "2021-08-02", "12:20"} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Validate(%q) 应返回错误", invalid)
+		}
+	}
+
+	// 序列化时原样输出，格式由 Validate 校验
+	data, err := json.Marshal(DelistNotification{OrderID: "A001", DelistTime: "2025/01/01 10:00:00"})
+	if err != nil || !strings.Contains(string(data), `"delistTime":"2025/01/01 10:00:00"`) {
+		t.Errorf("json.Marshal() = %s, %v", data, err)
+	}
+
+	client := newTestAPIClient(t, func(method, params string) *Response {
+		t.Errorf("格式错误的时间不应发起请求: %s", params)
+		return &Response{Code: "0000"}
+	})
+	if _, err := client.GetOrderCoordinate(&OrderCoordinateRequest{OrderID: "A001", CreatedStartTime: "yesterday"}); err == nil {
+		t.Error("GetOrderCoordinate() 格式错误的时间应返回错误")
+	}
+}
+
+func TestCreateOrderRequestValidateSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(o *OrderInfo)
+		wantPath string
+	}{
+		{"装货结束早于开始", func(o *OrderInfo) { o.DespatchEnd = "2025-01-20 07:00" }, "orderInfo.despatchEnd"},
+		{"收货早于装货结束", func(o *OrderInfo) { o.ReceiveDate = "2025-01-20 10:00" }, "orderInfo.receiveDate"},
+		{"报价结束晚于装货开始", func(o *OrderInfo) {
+			o.OrderModel = OrderModelBidding
+			o.ExpectTime = "2025-01-20 09:00"
+		}, "orderInfo.expectTime"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newValidCreateOrderRequest()
+			tt.modify(&req.OrderInfo)
+
+			err := req.Validate()
+			errs, _ := err.(ValidationErrors)
+			if errs.Field(tt.wantPath) == nil {
+				t.Errorf("缺少字段 %s 的错误，实际: %v", tt.wantPath, err)
+			}
+		})
+	}
+
+	req := newValidCreateOrderRequest()
+	req.OrderInfo.OrderModel = OrderModelBidding
+	req.OrderInfo.ExpectTime = "2025-01-19 18:00"
+	if err := req.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
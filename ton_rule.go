@@ -0,0 +1,183 @@
+package zczy
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrTonRuleNotFound 未找到亏涨吨扣款规则
+var ErrTonRuleNotFound = errors.New("未找到亏涨吨扣款规则")
+
+// TonRule 亏涨吨扣款规则，在本地描述平台上 OrderInfo.TonRuleID 对应的规则，用于预估结算金额
+//
+// 单价订单按摘单吨位计算运费，亏吨（收货吨位小于摘单吨位）在允许范围内不扣款，
+// 超出部分只按 DeductPrice 每吨扣款一次，扣款不超过 DeductCap；
+// 涨吨（收货吨位大于摘单吨位）默认不增加运费，GainPaid 为true时单价订单按收货吨位计算运费
+type TonRule struct {
+	ID          string   // 规则ID，对应 OrderInfo.TonRuleID
+	Name        string   // 规则名称
+	LossPercent string   // 允许亏吨比例（摘单吨位的百分比），例如 "0.3"
+	LossTons    Quantity // 允许亏吨吨数，与比例都填写时取较大者
+	DeductPrice Money    // 超出部分每吨扣款金额
+	DeductCap   Money    // 单笔扣款上限，为空时不限制
+	GainPaid    bool     // 涨吨是否按收货吨位计算运费（仅单价订单）
+}
+
+// Validate 校验规则参数
+func (r *TonRule) Validate() error {
+	v := &validator{}
+	v.required("id", r.ID)
+	v.percent("lossPercent", r.LossPercent)
+	v.quantity("lossTons", r.LossTons)
+	if v.required("deductPrice", string(r.DeductPrice)) {
+		v.money("deductPrice", r.DeductPrice)
+	}
+	v.money("deductCap", r.DeductCap)
+	return v.err()
+}
+
+// TonSettlement 亏涨吨结算预估结果，金额保留2位小数（四舍五入）
+type TonSettlement struct {
+	Delisted     Quantity // 摘单吨位
+	Received     Quantity // 收货吨位
+	LossTons     Decimal  // 亏吨吨数（涨吨时为0）
+	GainTons     Decimal  // 涨吨吨数（亏吨时为0）
+	AllowedLoss  Decimal  // 允许亏吨吨数
+	DeductTons   Decimal  // 需要扣款的吨数
+	BasisTonnage Decimal  // 计算运费的吨位（仅单价订单）：摘单吨位，GainPaid 且涨吨时为收货吨位
+	Freight      Money    // 扣款前运费
+	Deduction    Money    // 亏吨扣款
+	SettleMoney  Money    // 预估结算金额 = 运费 - 扣款
+}
+
+// FillReceipt 将收货吨位和预估结算金额填入回单确认请求，并清空承运方预估到手价
+func (s *TonSettlement) FillReceipt(req *ConfirmReceiptRequest) {
+	req.Tonnage = s.Received
+	req.SettleMoney = s.SettleMoney
+	req.ConsignorNoTaxMoney = ""
+}
+
+// Settle 按规则计算结算金额
+// 单价订单的 price 为每吨运费，运费 = 单价 × 摘单吨位，亏吨只通过扣款体现；包车价订单的 price 为整车运费
+func (r *TonRule) Settle(freightType FreightType, price Money, delisted, received Quantity) (*TonSettlement, error) {
+	priceValue, err := price.Decimal()
+	if err != nil {
+		return nil, fmt.Errorf("运费格式错误: %w", err)
+	}
+	weight, err := delisted.Decimal()
+	if err != nil {
+		return nil, fmt.Errorf("摘单吨位格式错误: %w", err)
+	}
+	tonnage, err := received.Decimal()
+	if err != nil {
+		return nil, fmt.Errorf("收货吨位格式错误: %w", err)
+	}
+	deductPrice, err := r.DeductPrice.Decimal()
+	if err != nil {
+		return nil, fmt.Errorf("扣款单价格式错误: %w", err)
+	}
+
+	allowed, err := (&TonnageTolerance{Percent: r.LossPercent, Tons: r.LossTons}).Allowed(delisted)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &TonSettlement{Delisted: delisted, Received: received, AllowedLoss: allowed}
+	diff := tonnage.Sub(weight)
+	if diff.Sign() < 0 {
+		s.LossTons = diff.Neg()
+	} else {
+		s.GainTons = diff
+	}
+	if s.LossTons.Cmp(allowed) > 0 {
+		s.DeductTons = s.LossTons.Sub(allowed)
+	}
+
+	var freight Decimal
+	switch model, _ := ParseFreightType(string(freightType)); model {
+	case FreightTypeUnitPrice:
+		s.BasisTonnage = weight
+		if r.GainPaid && s.GainTons.Sign() > 0 {
+			s.BasisTonnage = tonnage
+		}
+		freight = priceValue.Mul(s.BasisTonnage)
+	case FreightTypeWholeTruck:
+		freight = priceValue
+	default:
+		return nil, fmt.Errorf("未知的费用类型: %q", freightType)
+	}
+
+	deduction := s.DeductTons.Mul(deductPrice)
+	if r.DeductCap != "" {
+		limit, err := r.DeductCap.Decimal()
+		if err != nil {
+			return nil, fmt.Errorf("扣款上限格式错误: %w", err)
+		}
+		deduction = deduction.Min(limit)
+	}
+	freight = freight.Round(MoneyScale, RoundHalfUp)
+	deduction = deduction.Round(MoneyScale, RoundHalfUp).Min(freight)
+
+	s.Freight = NewMoney(freight, RoundHalfUp)
+	s.Deduction = NewMoney(deduction, RoundHalfUp)
+	s.SettleMoney = NewMoney(freight.Sub(deduction), RoundHalfUp)
+	return s, nil
+}
+
+// TonRuleEngine 按规则ID管理亏涨吨扣款规则
+type TonRuleEngine struct {
+	mu    sync.RWMutex
+	rules map[string]*TonRule
+}
+
+// NewTonRuleEngine 创建规则引擎
+func NewTonRuleEngine(rules ...*TonRule) (*TonRuleEngine, error) {
+	e := &TonRuleEngine{rules: make(map[string]*TonRule)}
+	for _, rule := range rules {
+		if err := e.Register(rule); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Register 注册或替换规则
+func (e *TonRuleEngine) Register(rule *TonRule) error {
+	if err := rule.Validate(); err != nil {
+		return fmt.Errorf("亏涨吨规则%s无效: %w", rule.ID, err)
+	}
+	r := *rule
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules[r.ID] = &r
+	return nil
+}
+
+// Rule 按ID查询规则
+func (e *TonRuleEngine) Rule(id string) (*TonRule, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	r, ok := e.rules[id]
+	if !ok {
+		return nil, false
+	}
+	rule := *r
+	return &rule, true
+}
+
+// Preview 根据订单的亏涨吨规则、费用类型和运费（TotalAmount）以及摘单吨位，预估收货后的结算金额
+func (e *TonRuleEngine) Preview(order *OrderInfo, delist *DelistNotification, received Quantity) (*TonSettlement, error) {
+	if order == nil {
+		return nil, errors.New("order is required")
+	}
+	if delist == nil || delist.Weight == "" {
+		return nil, errors.New("缺少摘单吨位")
+	}
+	rule, ok := e.Rule(order.TonRuleID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrTonRuleNotFound, order.TonRuleID)
+	}
+	return rule.Settle(order.FreightType, order.TotalAmount, delist.Weight, received)
+}
@@ -0,0 +1,87 @@
+package zczy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTonRuleSettle(t *testing.T) {
+	rule := &TonRule{ID: "R1", LossPercent: "0.3", LossTons: "0.05", DeductPrice: "500", DeductCap: "300"}
+
+	tests := []struct {
+		name        string
+		freightType FreightType
+		price       Money
+		received    Quantity
+		wantFreight Money
+		wantDeduct  Money
+		wantSettle  Money
+	}{
+		// 摘单30吨，允许亏吨 max(30×0.3%, 0.05) = 0.09吨
+		// 单价订单按摘单吨位计算运费，亏吨只扣超出部分一次：
+		// 收货29.5吨，运费 180×30 = 5400，扣款 (0.5-0.09)×500 = 205，结算 5195
+		{"亏吨在允许范围内", FreightTypeUnitPrice, "180.5", "29.95", "5415.00", "0.00", "5415.00"},
+		{"亏吨超出", FreightTypeUnitPrice, "180", "29.5", "5400.00", "205.00", "5195.00"},
+		{"扣款封顶", FreightTypeUnitPrice, "180", "28", "5400.00", "300.00", "5100.00"},
+		{"涨吨不加运费", FreightTypeUnitPrice, "180", "30.5", "5400.00", "0.00", "5400.00"},
+		{"包车价亏吨", FreightTypeWholeTruck, "5500", "29.5", "5500.00", "205.00", "5295.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := rule.Settle(tt.freightType, tt.price, "30", tt.received)
+			if err != nil {
+				t.Fatalf("Settle() error = %v", err)
+			}
+			if s.Freight != tt.wantFreight || s.Deduction != tt.wantDeduct || s.SettleMoney != tt.wantSettle {
+				t.Errorf("Settle() = %s - %s = %s, want %s - %s = %s",
+					s.Freight, s.Deduction, s.SettleMoney, tt.wantFreight, tt.wantDeduct, tt.wantSettle)
+			}
+		})
+	}
+
+	paid := *rule
+	paid.GainPaid = true
+	s, _ := paid.Settle(FreightTypeUnitPrice, "180", "30", "30.5")
+	if s.SettleMoney != "5490.00" || s.GainTons.String() != "0.5" {
+		t.Errorf("涨吨计费 = %s, gain = %s", s.SettleMoney, s.GainTons)
+	}
+}
+
+func TestTonRuleEnginePreview(t *testing.T) {
+	if _, err := NewTonRuleEngine(&TonRule{ID: "bad", LossPercent: "120"}); err == nil {
+		t.Error("无效规则应返回错误")
+	}
+
+	engine, err := NewTonRuleEngine(&TonRule{ID: "R1", LossTons: "0.1", DeductPrice: "400"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order := &OrderInfo{TonRuleID: "R1", FreightType: FreightTypeUnitPrice, TotalAmount: "200"}
+	s, err := engine.Preview(order, &DelistNotification{Weight: "20"}, "19.6")
+	if err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+	// 运费 200×20=4000，扣款 (0.4-0.1)×400=120
+	if s.SettleMoney != "3880.00" {
+		t.Errorf("SettleMoney = %s, want 3880.00", s.SettleMoney)
+	}
+
+	req := &ConfirmReceiptRequest{OrderID: "ZC001", ConsignorNoTaxMoney: "3000", SettleApplyFlag: "1"}
+	s.FillReceipt(req)
+	if err := req.Validate(); err != nil || req.Tonnage != "19.6" {
+		t.Errorf("FillReceipt() = %+v, Validate() = %v", req, err)
+	}
+
+	if _, err := engine.Preview(order, nil, "19.6"); err == nil {
+		t.Error("缺少摘单通知应返回错误")
+	}
+	if _, err := engine.Preview(nil, &DelistNotification{Weight: "20"}, "19.6"); err == nil {
+		t.Error("缺少订单应返回错误")
+	}
+
+	order.TonRuleID = "R2"
+	if _, err := engine.Preview(order, &DelistNotification{Weight: "20"}, "19.6"); !errors.Is(err, ErrTonRuleNotFound) {
+		t.Errorf("Preview() error = %v, want ErrTonRuleNotFound", err)
+	}
+}